@@ -0,0 +1,109 @@
+// Package clock provides the time source of the time-based stream operations. It allows
+// replacing the system clock by a manually-driven clock, so the time-based operations
+// can be tested deterministically, without sleeping.
+package clock
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Clock provides the current time and timers to the time-based stream operations.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// After waits for the duration to elapse and then sends the current time on the
+	// returned channel.
+	After(d time.Duration) <-chan time.Time
+}
+
+// System returns a Clock that reads the time from the operating system, as the functions
+// in the time package do.
+func System() Clock {
+	return systemClock{}
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Manual is a Clock whose time only changes when it is explicitly set or advanced.
+// Its timers fire when the time is advanced beyond their deadline.
+// It is safe for concurrent use.
+type Manual struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []manualTimer
+}
+
+type manualTimer struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// NewManual returns a Manual clock whose current time is the provided start time.
+func NewManual(start time.Time) *Manual {
+	return &Manual{now: start}
+}
+
+// Now returns the current time of the clock.
+func (m *Manual) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.now
+}
+
+// After returns a channel that receives the current time of the clock once it has been
+// advanced by, at least, the provided duration.
+func (m *Manual) After(d time.Duration) <-chan time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// buffered so firing a timer never blocks the clock
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- m.now
+		return ch
+	}
+	m.timers = append(m.timers, manualTimer{deadline: m.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the current time of the clock forward by the provided duration,
+// firing all the timers whose deadline has been reached.
+func (m *Manual) Advance(d time.Duration) {
+	m.mu.Lock()
+	now := m.now.Add(d)
+	m.mu.Unlock()
+	m.Set(now)
+}
+
+// Set changes the current time of the clock, firing all the timers whose deadline
+// has been reached.
+func (m *Manual) Set(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = now
+	m.timers = slices.DeleteFunc(m.timers, func(t manualTimer) bool {
+		if t.deadline.After(now) {
+			return false
+		}
+		t.ch <- now
+		return true
+	})
+}
+
+// Timers returns the number of timers that are waiting for the clock to reach their deadline.
+// It allows tests to wait until a time-based operation has armed its timer, before
+// advancing the clock.
+func (m *Manual) Timers() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.timers)
+}
@@ -0,0 +1,42 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManual(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := NewManual(start)
+	assert.Equal(t, start, clk.Now())
+
+	immediate := clk.After(0)
+	first := clk.After(time.Second)
+	second := clk.After(2 * time.Second)
+	assert.Equal(t, start, <-immediate)
+	assert.Equal(t, 2, clk.Timers())
+
+	clk.Advance(500 * time.Millisecond)
+	assert.Empty(t, first)
+	assert.Equal(t, 2, clk.Timers())
+
+	clk.Advance(time.Second)
+	assert.Equal(t, start.Add(1500*time.Millisecond), <-first)
+	assert.Empty(t, second)
+	assert.Equal(t, 1, clk.Timers())
+
+	clk.Set(start.Add(time.Minute))
+	assert.Equal(t, start.Add(time.Minute), <-second)
+	assert.Zero(t, clk.Timers())
+	assert.Equal(t, start.Add(time.Minute), clk.Now())
+}
+
+func TestSystem(t *testing.T) {
+	clk := System()
+	before := time.Now()
+	fired := <-clk.After(time.Millisecond)
+	assert.False(t, fired.Before(before))
+	assert.False(t, clk.Now().Before(fired))
+}
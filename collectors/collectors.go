@@ -0,0 +1,168 @@
+// Package collectors provides implementations of stream.Collector that perform common
+// mutable reduction operations, such as accumulating elements into collections or
+// summarizing them. They are intended to be used with the stream.Collect function.
+package collectors
+
+import (
+	"strings"
+
+	"golang.org/x/exp/constraints"
+
+	"github.com/mariomac/gostream/item"
+	"github.com/mariomac/gostream/stream"
+)
+
+// Of returns a stream.Collector described by the given supplier, accumulator and combiner
+// functions, whose final result is the accumulated container.
+func Of[T, A any](supplier func() A, accumulator func(A, T) A, combiner func(A, A) A) stream.Collector[T, A, A] {
+	return stream.Collector[T, A, A]{
+		Supplier:    supplier,
+		Accumulator: accumulator,
+		Combiner:    combiner,
+		Finisher:    identity[A],
+	}
+}
+
+func identity[T any](i T) T {
+	return i
+}
+
+// Counting returns a Collector that counts the number of input elements.
+func Counting[T any]() stream.Collector[T, int, int] {
+	return Of(
+		func() int { return 0 },
+		func(count int, _ T) int { return count + 1 },
+		item.Add[int],
+	)
+}
+
+// Summing returns a Collector that produces the sum of the input elements.
+// If no elements are present, the result is 0.
+func Summing[N item.Number]() stream.Collector[N, N, N] {
+	return Of(
+		func() N { return 0 },
+		item.Add[N],
+		item.Add[N],
+	)
+}
+
+// Average is the accumulation container of the Averaging Collector.
+type Average struct {
+	// Sum of the accumulated elements
+	Sum float64
+	// Count of the accumulated elements
+	Count int
+}
+
+// Averaging returns a Collector that produces the arithmetic mean of the input elements.
+// If no elements are present, the result is 0.
+func Averaging[N constraints.Integer | constraints.Float]() stream.Collector[N, Average, float64] {
+	return stream.Collector[N, Average, float64]{
+		Supplier: func() Average { return Average{} },
+		Accumulator: func(a Average, n N) Average {
+			a.Sum += float64(n)
+			a.Count++
+			return a
+		},
+		Combiner: func(a, b Average) Average {
+			return Average{Sum: a.Sum + b.Sum, Count: a.Count + b.Count}
+		},
+		Finisher: func(a Average) float64 {
+			if a.Count == 0 {
+				return 0
+			}
+			return a.Sum / float64(a.Count)
+		},
+	}
+}
+
+// Joining returns a Collector that concatenates the input strings, separated by
+// the provided separator, in the same order as they are found in the stream.
+func Joining(separator string) stream.Collector[string, []string, string] {
+	return stream.Collector[string, []string, string]{
+		Supplier:    func() []string { return nil },
+		Accumulator: appendItem[string],
+		Combiner:    appendAll[string],
+		Finisher: func(s []string) string {
+			return strings.Join(s, separator)
+		},
+	}
+}
+
+// ToSlice returns a Collector that accumulates the input elements into a slice,
+// in the same order as they are found in the stream.
+func ToSlice[T any]() stream.Collector[T, []T, []T] {
+	return Of(
+		func() []T { return nil },
+		appendItem[T],
+		appendAll[T],
+	)
+}
+
+// ToSet returns a Collector that accumulates the input elements into a set,
+// implemented as a map whose keys are the distinct input elements.
+func ToSet[T comparable]() stream.Collector[T, map[T]struct{}, map[T]struct{}] {
+	return Of(
+		func() map[T]struct{} { return map[T]struct{}{} },
+		func(set map[T]struct{}, i T) map[T]struct{} {
+			set[i] = struct{}{}
+			return set
+		},
+		func(a, b map[T]struct{}) map[T]struct{} {
+			for i := range b {
+				a[i] = struct{}{}
+			}
+			return a
+		},
+	)
+}
+
+// Mapping adapts the downstream Collector to accept elements of type T by applying the
+// mapper function to each input element before accumulation.
+func Mapping[T, U, A, R any](mapper func(T) U, downstream stream.Collector[U, A, R]) stream.Collector[T, A, R] {
+	return stream.Collector[T, A, R]{
+		Supplier: downstream.Supplier,
+		Accumulator: func(a A, t T) A {
+			return downstream.Accumulator(a, mapper(t))
+		},
+		Combiner: downstream.Combiner,
+		Finisher: downstream.Finisher,
+	}
+}
+
+// Filtering adapts the downstream Collector by only accumulating the input elements
+// that match the provided predicate.
+func Filtering[T, A, R any](predicate func(T) bool, downstream stream.Collector[T, A, R]) stream.Collector[T, A, R] {
+	return stream.Collector[T, A, R]{
+		Supplier: downstream.Supplier,
+		Accumulator: func(a A, t T) A {
+			if predicate(t) {
+				return downstream.Accumulator(a, t)
+			}
+			return a
+		},
+		Combiner: downstream.Combiner,
+		Finisher: downstream.Finisher,
+	}
+}
+
+// CollectingAndThen adapts the downstream Collector to perform an additional finishing
+// transformation over its result.
+func CollectingAndThen[T, A, R, RR any](downstream stream.Collector[T, A, R], finisher func(R) RR) stream.Collector[T, A, RR] {
+	return stream.Collector[T, A, RR]{
+		Supplier:    downstream.Supplier,
+		Accumulator: downstream.Accumulator,
+		Combiner:    downstream.Combiner,
+		Finisher: func(a A) RR {
+			return finisher(downstream.Finisher(a))
+		},
+	}
+}
+
+func appendItem[T any](s []T, i T) []T {
+	return append(s, i)
+}
+
+func appendAll[T any](a, b []T) []T {
+	return append(a, b...)
+}
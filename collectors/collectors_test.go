@@ -0,0 +1,72 @@
+package collectors
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mariomac/gostream/stream"
+)
+
+func TestCounting(t *testing.T) {
+	assert.Equal(t, 0, stream.Collect(stream.Empty[string](), Counting[string]()))
+	assert.Equal(t, 3, stream.Collect(stream.Of("a", "b", "c"), Counting[string]()))
+}
+
+func TestSumming(t *testing.T) {
+	assert.Equal(t, 0, stream.Collect(stream.Empty[int](), Summing[int]()))
+	assert.Equal(t, 6.5, stream.Collect(stream.Of(1.5, 2, 3), Summing[float64]()))
+}
+
+func TestAveraging(t *testing.T) {
+	assert.Zero(t, stream.Collect(stream.Empty[int](), Averaging[int]()))
+	assert.Equal(t, 2.5, stream.Collect(stream.Of(1, 2, 3, 4), Averaging[int]()))
+
+	// the collector type can be named, and its container inspected
+	var avg stream.Collector[float64, Average, float64] = Averaging[float64]()
+	assert.Equal(t, Average{Sum: 3, Count: 2}, avg.Accumulator(avg.Accumulator(avg.Supplier(), 1), 2))
+}
+
+func TestJoining(t *testing.T) {
+	assert.Empty(t, stream.Collect(stream.Empty[string](), Joining(", ")))
+	assert.Equal(t, "hello, my, friend",
+		stream.Collect(stream.Of("hello", "my", "friend"), Joining(", ")))
+}
+
+func TestToSliceAndToSet(t *testing.T) {
+	assert.Equal(t, []int{3, 1, 3, 2},
+		stream.Collect(stream.Of(3, 1, 3, 2), ToSlice[int]()))
+	assert.Equal(t, map[int]struct{}{1: {}, 2: {}, 3: {}},
+		stream.Collect(stream.Of(3, 1, 3, 2), ToSet[int]()))
+}
+
+func TestMappingAndFiltering(t *testing.T) {
+	words := stream.Of("hello", "my", "dear", "friend")
+	assert.Equal(t, 17, stream.Collect(words, Mapping(func(s string) int {
+		return len(s)
+	}, Summing[int]())))
+
+	assert.Equal(t, "HELLO-FRIEND", stream.Collect(words,
+		Filtering(func(s string) bool {
+			return len(s) > 4
+		}, Mapping(strings.ToUpper, Joining("-")))))
+}
+
+func TestCombiners(t *testing.T) {
+	// combiners allow accumulating different parts of a stream separately
+	sum := Summing[int]()
+	assert.Equal(t, 6, sum.Finisher(sum.Combiner(
+		sum.Accumulator(sum.Accumulator(sum.Supplier(), 1), 2),
+		sum.Accumulator(sum.Supplier(), 3))))
+
+	avg := Averaging[int]()
+	assert.Equal(t, 2.0, avg.Finisher(avg.Combiner(
+		avg.Accumulator(avg.Accumulator(avg.Supplier(), 1), 2),
+		avg.Accumulator(avg.Supplier(), 3))))
+
+	join := Joining(",")
+	assert.Equal(t, "a,b,c", join.Finisher(join.Combiner(
+		join.Accumulator(join.Accumulator(join.Supplier(), "a"), "b"),
+		join.Accumulator(join.Supplier(), "c"))))
+}
@@ -0,0 +1,146 @@
+package collectors
+
+import (
+	"github.com/mariomac/gostream/item"
+	"github.com/mariomac/gostream/order"
+	"github.com/mariomac/gostream/stream"
+)
+
+// GroupingBy returns a Collector that groups the input elements according to the key
+// returned by the keyFn function, and returns the groups in a map whose values are the
+// slices of elements that share the same key, in the same order as they are found
+// in the stream.
+// The returned map can be converted back into a stream of item.Pair via stream.OfMap.
+func GroupingBy[T any, K comparable](keyFn func(T) K) stream.Collector[T, map[K][]T, map[K][]T] {
+	return GroupingByWith(keyFn, ToSlice[T]())
+}
+
+// GroupingByWith returns a Collector that groups the input elements according to the key
+// returned by the keyFn function, and performs a reduction operation over the elements of
+// each group using the downstream Collector (e.g. Counting, or another GroupingBy for
+// nested groups).
+func GroupingByWith[T any, K comparable, A, R any](
+	keyFn func(T) K, downstream stream.Collector[T, A, R],
+) stream.Collector[T, map[K]A, map[K]R] {
+	return stream.Collector[T, map[K]A, map[K]R]{
+		Supplier: func() map[K]A {
+			return map[K]A{}
+		},
+		Accumulator: func(groups map[K]A, t T) map[K]A {
+			key := keyFn(t)
+			group, ok := groups[key]
+			if !ok {
+				group = downstream.Supplier()
+			}
+			groups[key] = downstream.Accumulator(group, t)
+			return groups
+		},
+		Combiner: func(a, b map[K]A) map[K]A {
+			for key, bGroup := range b {
+				if aGroup, ok := a[key]; ok {
+					a[key] = downstream.Combiner(aGroup, bGroup)
+				} else {
+					a[key] = bGroup
+				}
+			}
+			return a
+		},
+		Finisher: func(groups map[K]A) map[K]R {
+			out := make(map[K]R, len(groups))
+			for key, group := range groups {
+				out[key] = downstream.Finisher(group)
+			}
+			return out
+		},
+	}
+}
+
+// PartitioningBy returns a Collector that partitions the input elements according to
+// the provided predicate. The returned map always contains the true and false keys: the
+// former for the elements matching the predicate, and the latter for the rest of elements.
+func PartitioningBy[T any](predicate func(T) bool) stream.Collector[T, map[bool][]T, map[bool][]T] {
+	return PartitioningByWith(predicate, ToSlice[T]())
+}
+
+// PartitioningByWith returns a Collector that partitions the input elements according to
+// the provided predicate, and performs a reduction operation over the elements of each
+// partition using the downstream Collector. The returned map always contains the true and
+// false keys, even if some of the partitions is empty.
+func PartitioningByWith[T, A, R any](
+	predicate func(T) bool, downstream stream.Collector[T, A, R],
+) stream.Collector[T, map[bool]A, map[bool]R] {
+	grouping := GroupingByWith(predicate, downstream)
+	grouping.Supplier = func() map[bool]A {
+		return map[bool]A{
+			true:  downstream.Supplier(),
+			false: downstream.Supplier(),
+		}
+	}
+	return grouping
+}
+
+// ToMap returns a Collector that accumulates item.Pair elements into a map, where the
+// Key/Val fields of the item.Pair represents the key/value of the map, respectively.
+// It is the Collector equivalent to the stream.ToMap function.
+func ToMap[K comparable, V any]() stream.Collector[item.Pair[K, V], map[K]V, map[K]V] {
+	return Of(
+		func() map[K]V { return map[K]V{} },
+		func(m map[K]V, p item.Pair[K, V]) map[K]V {
+			m[p.Key] = p.Val
+			return m
+		},
+		func(a, b map[K]V) map[K]V {
+			for k, v := range b {
+				a[k] = v
+			}
+			return a
+		},
+	)
+}
+
+// Reducing returns a Collector that performs a reduction of the input elements using the
+// provided associative accumulator function. The identity value is the result of
+// the reduction when there are no input elements.
+func Reducing[T any](identity T, accumulator func(a, b T) T) stream.Collector[T, T, T] {
+	return Of(
+		func() T { return identity },
+		accumulator,
+		accumulator,
+	)
+}
+
+// MaxBy returns a Collector that produces the maximal element according to the provided
+// comparator, or an absent item.Optional if no elements are present.
+func MaxBy[T any](cmp order.Comparator[T]) stream.Collector[T, item.Optional[T], item.Optional[T]] {
+	return selecting(func(candidate, current T) bool {
+		return cmp(candidate, current) > 0
+	})
+}
+
+// MinBy returns a Collector that produces the minimal element according to the provided
+// comparator, or an absent item.Optional if no elements are present.
+func MinBy[T any](cmp order.Comparator[T]) stream.Collector[T, item.Optional[T], item.Optional[T]] {
+	return selecting(func(candidate, current T) bool {
+		return cmp(candidate, current) < 0
+	})
+}
+
+// selecting returns a collector that keeps the element that is preferred over the rest
+func selecting[T any](preferred func(candidate, current T) bool) stream.Collector[T, item.Optional[T], item.Optional[T]] {
+	accumulate := func(o item.Optional[T], t T) item.Optional[T] {
+		if !o.Present || preferred(t, o.Val) {
+			return item.Some(t)
+		}
+		return o
+	}
+	return Of(
+		item.None[T],
+		accumulate,
+		func(a, b item.Optional[T]) item.Optional[T] {
+			if !b.Present {
+				return a
+			}
+			return accumulate(a, b.Val)
+		},
+	)
+}
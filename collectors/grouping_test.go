@@ -0,0 +1,106 @@
+package collectors
+
+import (
+	"cmp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mariomac/gostream/item"
+	"github.com/mariomac/gostream/order"
+	"github.com/mariomac/gostream/stream"
+)
+
+type employee struct {
+	name   string
+	dept   string
+	city   string
+	salary int
+}
+
+var employees = stream.Of(
+	employee{name: "Ana", dept: "eng", city: "BCN", salary: 50},
+	employee{name: "Bob", dept: "eng", city: "MAD", salary: 60},
+	employee{name: "Cai", dept: "ops", city: "BCN", salary: 40},
+	employee{name: "Dan", dept: "eng", city: "BCN", salary: 55},
+	employee{name: "Eva", dept: "hr", city: "MAD", salary: 45},
+)
+
+func name(e employee) string { return e.name }
+
+func dept(e employee) string { return e.dept }
+
+func TestGroupingBy(t *testing.T) {
+	groups := stream.Collect(employees, GroupingBy(dept))
+	assert.Equal(t, map[string][]string{
+		"eng": {"Ana", "Bob", "Dan"},
+		"ops": {"Cai"},
+		"hr":  {"Eva"},
+	}, stream.ToMap(stream.Map(stream.OfMap(groups),
+		func(p item.Pair[string, []employee]) item.Pair[string, []string] {
+			return item.Pair[string, []string]{Key: p.Key, Val: stream.Map(stream.OfSlice(p.Val), name).ToSlice()}
+		})))
+
+	assert.Empty(t, stream.Collect(stream.Empty[employee](), GroupingBy(dept)))
+}
+
+func TestGroupingByWith(t *testing.T) {
+	assert.Equal(t, map[string]int{"eng": 3, "ops": 1, "hr": 1},
+		stream.Collect(employees, GroupingByWith(dept, Counting[employee]())))
+
+	assert.Equal(t, map[string]int{"eng": 165, "ops": 40, "hr": 45},
+		stream.Collect(employees, GroupingByWith(dept,
+			Mapping(func(e employee) int { return e.salary }, Summing[int]()))))
+
+	assert.Equal(t, map[string]string{"eng": "Bob", "ops": "Cai", "hr": "Eva"},
+		stream.Collect(employees, GroupingByWith(dept,
+			CollectingAndThen(MaxBy(func(a, b employee) int {
+				return cmp.Compare(a.salary, b.salary)
+			}), func(e item.Optional[employee]) string {
+				return name(e.Val)
+			}))))
+}
+
+func TestGroupingBy_Nested(t *testing.T) {
+	assert.Equal(t, map[string]map[string][]string{
+		"eng": {"BCN": {"Ana", "Dan"}, "MAD": {"Bob"}},
+		"ops": {"BCN": {"Cai"}},
+		"hr":  {"MAD": {"Eva"}},
+	}, stream.Collect(employees, GroupingByWith(dept,
+		GroupingByWith(func(e employee) string { return e.city },
+			Mapping(name, ToSlice[string]())))))
+}
+
+func TestPartitioningBy(t *testing.T) {
+	highSalary := func(e employee) bool { return e.salary > 50 }
+	assert.Equal(t, map[bool]int{true: 2, false: 3},
+		stream.Collect(employees, PartitioningByWith(highSalary, Counting[employee]())))
+
+	parts := stream.Collect(stream.Of(1, 2, 3), PartitioningBy(item.GreaterThan(5)))
+	assert.Equal(t, map[bool][]int{true: nil, false: {1, 2, 3}}, parts)
+}
+
+func TestToMap(t *testing.T) {
+	salaries := stream.Map(employees, func(e employee) item.Pair[string, int] {
+		return item.Pair[string, int]{Key: e.name, Val: e.salary}
+	})
+	assert.Equal(t, stream.ToMap(salaries), stream.Collect(salaries, ToMap[string, int]()))
+}
+
+func TestReducingMaxByMinBy(t *testing.T) {
+	assert.Equal(t, 24, stream.Collect(stream.Of(1, 2, 3, 4), Reducing(1, item.Multiply[int])))
+	assert.Equal(t, 1, stream.Collect(stream.Empty[int](), Reducing(1, item.Multiply[int])))
+
+	assert.Equal(t, item.Some("my"), stream.Collect(stream.Of("hello", "my", "friend"), MaxBy(cmp.Compare[string])))
+	assert.Equal(t, item.Some("friend"), stream.Collect(stream.Of("hello", "my", "friend"), MinBy(cmp.Compare[string])))
+	assert.Equal(t, item.None[string](), stream.Collect(stream.Empty[string](), MaxBy(order.Inverse(cmp.Compare[string]))))
+	// an empty value is told apart from an empty group
+	assert.Equal(t, item.Some(""), stream.Collect(stream.Of("", ""), MinBy(cmp.Compare[string])))
+
+	// the collectors can be combined in parallel
+	maxBy := MaxBy(cmp.Compare[int])
+	assert.Equal(t, item.Some(3), maxBy.Finisher(maxBy.Combiner(
+		maxBy.Accumulator(maxBy.Supplier(), 3), maxBy.Supplier())))
+	assert.Equal(t, item.Some(3), maxBy.Finisher(maxBy.Combiner(
+		maxBy.Supplier(), maxBy.Accumulator(maxBy.Supplier(), 3))))
+}
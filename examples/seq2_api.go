@@ -26,9 +26,9 @@ func main_seq2_api() {
 		})
 
 	// 1. Convert each os.DirEntry to a name/size item.Pair (stream.Map function)
-	// 2. Sorting the stream by file size in descending order
-	//    (using Sorted method cmp.Compare and order.Inverse)
-	// 3. Limit the stream size to the top 3 files by size (using Limit method)
+	// 2. Get the top 3 files by size, in descending order
+	//    (using TopK method with cmp.Compare and order.Inverse, which is
+	//    equivalent to Sorted followed by Limit, but only keeps 3 files in memory)
 	sizeTop3 := stream.Map(justFiles,
 		func(entry os.DirEntry) item.Pair[string, int64] {
 			info, _ := entry.Info()
@@ -37,10 +37,9 @@ func main_seq2_api() {
 				Val: info.Size(),
 			}
 		}).
-		Sorted(order.Inverse(func(a, b item.Pair[string, int64]) int {
+		TopK(order.Inverse(func(a, b item.Pair[string, int64]) int {
 			return cmp.Compare(a.Val, b.Val)
-		})).
-		Limit(3)
+		}), 3)
 
 	// stream.Seq2 function allows iterating the stream within a for..range
 	fmt.Println("Top 3 files:")
@@ -20,6 +20,41 @@ type Pair[K comparable, V any] struct {
 	Val V
 }
 
+// Optional value, which might be absent (e.g. the missing side of an outer join)
+type Optional[T any] struct {
+	Val     T
+	Present bool
+}
+
+// Some returns an Optional containing the provided value
+func Some[T any](val T) Optional[T] {
+	return Optional[T]{Val: val, Present: true}
+}
+
+// None returns an absent Optional
+func None[T any]() Optional[T] {
+	return Optional[T]{}
+}
+
+// Get returns the contained value, and whether it is present
+func (o Optional[T]) Get() (T, bool) {
+	return o.Val, o.Present
+}
+
+// OrElse returns the contained value if it is present, or the provided default otherwise
+func (o Optional[T]) OrElse(def T) T {
+	if o.Present {
+		return o.Val
+	}
+	return def
+}
+
+// Joined contains the values of the left and right sides of a join
+type Joined[L, R any] struct {
+	Left  L
+	Right R
+}
+
 // Add the two arguments using the plus + operator
 func Add[T Addable](a, b T) T {
 	return a + b
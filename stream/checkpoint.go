@@ -0,0 +1,314 @@
+package stream
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mariomac/gostream/clock"
+	"github.com/mariomac/gostream/item"
+)
+
+// Checkpointer periodically saves the progress of a stream into a local file, so the same
+// stream definition can resume from the last checkpoint after a crash or a restart.
+// A checkpoint records the position of the source of the stream (see CheckpointedSlice,
+// CheckpointedIterate and CheckpointedChannel) along with the entries of the state stores
+// of its stateful operations (see CheckpointState). Stateful operations are checkpointed through
+// their StateStore-based counterparts: DistinctWith for Distinct, ScanWith for Scan, ScanByKey
+// for running aggregations by key, and AggregateByKeyWith for aggregations and grouping by key.
+//
+// A checkpoint is taken by the source, when it is asked for a new element, so it is consistent
+// as long as the operations between the source and the terminal operation process each element
+// before pulling the next one (e.g. Map, Filter, DistinctWith or ScanByKey). Then, on resume,
+// the elements that were processed after the last checkpoint are processed again.
+// The elements that are buffered by other operations (e.g. Chunk, Window or parallel stages)
+// when a checkpoint is taken are not processed again after resuming.
+type Checkpointer struct {
+	path     string
+	interval time.Duration
+	clk      clock.Clock
+
+	mu sync.Mutex
+	// last checkpoint, either loaded or saved
+	last     checkpoint
+	lastSave time.Time
+	states   map[string]func(io.Writer) error
+	// hasSource is true when a checkpointed source is tracked by this Checkpointer
+	hasSource bool
+}
+
+// checkpoint is the content of a checkpoint file
+type checkpoint struct {
+	// Position of the source, encoded by the source
+	Position []byte
+	// States snapshots, by name
+	States map[string][]byte
+}
+
+// CheckpointOption configures a Checkpointer created with NewCheckpointer.
+type CheckpointOption func(*Checkpointer)
+
+// WithCheckpointClock replaces the system clock that measures the checkpoint interval, e.g.
+// by a clock.Manual in tests.
+func WithCheckpointClock(clk clock.Clock) CheckpointOption {
+	return func(c *Checkpointer) {
+		c.clk = clk
+	}
+}
+
+// NewCheckpointer returns a Checkpointer that saves the checkpoints into the provided path, at
+// most once per interval. A zero interval saves a checkpoint before each element of the source.
+// If the file exists, the stream resumes from the checkpoint it contains.
+// It panics if interval is negative.
+func NewCheckpointer(path string, interval time.Duration, opts ...CheckpointOption) (*Checkpointer, error) {
+	if interval < 0 {
+		panic(fmt.Sprintf("checkpoint interval can't be negative. Got: %v", interval))
+	}
+	c := &Checkpointer{path: path, interval: interval, clk: clock.System(), states: map[string]func(io.Writer) error{}}
+	for _, opt := range opts {
+		opt(c)
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("opening checkpoint: %w", err)
+	}
+	defer f.Close()
+	if err := gob.NewDecoder(f).Decode(&c.last); err != nil {
+		return nil, fmt.Errorf("reading checkpoint %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// CheckpointState restores the provided store from the last checkpoint, if any, and records its
+// entries in the next checkpoints, under the provided name. The entries are encoded with the
+// provided Codec (GobCodec if nil).
+// It must be invoked before iterating the checkpointed stream, and the name must be unique
+// within the Checkpointer.
+func CheckpointState[K comparable, V any](
+	cp *Checkpointer, name string, store StateStore[K, V], codec Codec[item.Pair[K, V]],
+) error {
+	if codec == nil {
+		codec = GobCodec[item.Pair[K, V]]()
+	}
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	if _, ok := cp.states[name]; ok {
+		return fmt.Errorf("state %q is already checkpointed", name)
+	}
+	if snapshot, ok := cp.last.States[name]; ok {
+		if err := RestoreStore(store, bytes.NewReader(snapshot), codec); err != nil {
+			return fmt.Errorf("restoring state %q: %w", name, err)
+		}
+	}
+	cp.states[name] = func(w io.Writer) error {
+		return SnapshotStore(store, w, codec)
+	}
+	return nil
+}
+
+// CheckpointedSlice creates a Stream from a slice, whose position is recorded by the provided
+// Checkpointer. Its iteration starts after the elements that had been processed at the last
+// checkpoint.
+// It panics if the Checkpointer already tracks another source.
+func CheckpointedSlice[T any](cp *Checkpointer, elems []T) Stream[T] {
+	cp.trackSource()
+	codec := GobCodec[int]()
+	return &iterableStream[T]{supply: func(ex *execution) iterator[T] {
+		var idx int
+		if _, ok := lastPosition(cp, ex, &idx, codec); !ok {
+			return finishedIterator[T]
+		}
+		return func() (T, bool) {
+			if ex.cancelled() || !saveCheckpoint(cp, ex, idx, codec, idx >= len(elems)) {
+				return finishedIterator[T]()
+			}
+			if idx >= len(elems) {
+				return finishedIterator[T]()
+			}
+			n := elems[idx]
+			idx++
+			return n, true
+		}
+	}}
+}
+
+// CheckpointedIterate returns a stream equivalent to Iterate, whose position is recorded by the
+// provided Checkpointer. If there is a checkpoint, the iteration starts from the element that
+// followed the elements that had been processed, instead of the seed. The position is encoded
+// with the provided Codec (GobCodec if nil).
+// It panics if the Checkpointer already tracks another source.
+func CheckpointedIterate[T any](cp *Checkpointer, seed T, f func(T) T, codec Codec[T]) Stream[T] {
+	if codec == nil {
+		codec = GobCodec[T]()
+	}
+	cp.trackSource()
+	return &iterableStream[T]{
+		infinite: true,
+		supply: func(ex *execution) iterator[T] {
+			lastElement := seed
+			if _, ok := lastPosition(cp, ex, &lastElement, codec); !ok {
+				return finishedIterator[T]
+			}
+			return func() (T, bool) {
+				if ex.cancelled() || !saveCheckpoint(cp, ex, lastElement, codec, false) {
+					return finishedIterator[T]()
+				}
+				i := lastElement
+				lastElement = f(lastElement)
+				return i, true
+			}
+		},
+	}
+}
+
+// CheckpointedChannel creates a Stream from a channel, whose position is recorded by the
+// provided Checkpointer as the offset of the last processed element, as returned by the offset
+// function. Offsets must be increasing in the order of the channel.
+// Since the elements of a channel can't be replayed, the producer of the channel must resume
+// sending the elements after the offset returned by the ChannelOffset method. The received
+// elements whose offset is not after the checkpointed offset are discarded, so the producer
+// can also send again some already processed elements.
+// It panics if the Checkpointer already tracks another source.
+func CheckpointedChannel[T any](cp *Checkpointer, source <-chan T, offset func(T) int64) Stream[T] {
+	cp.trackSource()
+	codec := GobCodec[int64]()
+	return &iterableStream[T]{supply: func(ex *execution) iterator[T] {
+		var last int64
+		found, ok := lastPosition(cp, ex, &last, codec)
+		if !ok {
+			return finishedIterator[T]
+		}
+		return func() (T, bool) {
+			// the checkpoint is saved before waiting for the next element, so it is up to date
+			// while the channel is idle
+			if found && !saveCheckpoint(cp, ex, last, codec, false) {
+				return finishedIterator[T]()
+			}
+			for {
+				select {
+				case v, ok := <-source:
+					if !ok {
+						if found {
+							saveCheckpoint(cp, ex, last, codec, true)
+						}
+						return finishedIterator[T]()
+					}
+					o := offset(v)
+					if found && o <= last {
+						continue
+					}
+					last, found = o, true
+					return v, true
+				case <-ex.ctx.Done():
+					return finishedIterator[T]()
+				}
+			}
+		}
+	}}
+}
+
+// ChannelOffset returns the offset of the last element of a CheckpointedChannel that had been
+// processed at the last checkpoint, and false if there is no checkpoint.
+func (c *Checkpointer) ChannelOffset() (int64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var offset int64
+	if c.last.Position == nil ||
+		GobCodec[int64]().NewDecoder(bytes.NewReader(c.last.Position)).Decode(&offset) != nil {
+		return 0, false
+	}
+	return offset, true
+}
+
+func (c *Checkpointer) trackSource() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.hasSource {
+		panic("a Checkpointer can only track a single source")
+	}
+	c.hasSource = true
+}
+
+// lastPosition decodes the position of the last checkpoint into pos, and returns whether there
+// was a checkpoint. If the position can't be decoded, the error is reported to the execution
+// and it returns false as second value.
+func lastPosition[P any](cp *Checkpointer, ex *execution, pos *P, codec Codec[P]) (found, ok bool) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	if cp.last.Position == nil {
+		return false, true
+	}
+	if err := codec.NewDecoder(bytes.NewReader(cp.last.Position)).Decode(pos); err != nil {
+		ex.fail(fmt.Errorf("reading checkpoint position: %w", err))
+		return false, false
+	}
+	return true, true
+}
+
+// saveCheckpoint saves a checkpoint with the provided position if the checkpoint interval has
+// passed since the last checkpoint, or if force is true. It returns false if the checkpoint
+// can't be saved and the execution must stop.
+func saveCheckpoint[P any](cp *Checkpointer, ex *execution, pos P, codec Codec[P], force bool) bool {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	now := cp.clk.Now()
+	if cp.lastSave.IsZero() {
+		cp.lastSave = now
+	}
+	if !force && now.Sub(cp.lastSave) < cp.interval {
+		return true
+	}
+	position := bytes.Buffer{}
+	if err := codec.NewEncoder(&position).Encode(pos); err != nil {
+		return ex.fail(fmt.Errorf("saving checkpoint position: %w", err))
+	}
+	// the states only change when the position changes
+	if cp.last.Position != nil && bytes.Equal(position.Bytes(), cp.last.Position) {
+		return true
+	}
+	next := checkpoint{Position: position.Bytes(), States: map[string][]byte{}}
+	for name, snapshot := range cp.states {
+		state := bytes.Buffer{}
+		if err := snapshot(&state); err != nil {
+			return ex.fail(fmt.Errorf("saving checkpoint state %q: %w", name, err))
+		}
+		next.States[name] = state.Bytes()
+	}
+	if err := writeCheckpoint(cp.path, next); err != nil {
+		return ex.fail(err)
+	}
+	cp.last, cp.lastSave = next, now
+	return true
+}
+
+// writeCheckpoint writes the checkpoint into a temporary file that atomically replaces the
+// checkpoint file, so a crash while writing never leaves a corrupt checkpoint
+func writeCheckpoint(path string, cp checkpoint) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".gostream-checkpoint-*")
+	if err != nil {
+		return fmt.Errorf("saving checkpoint: %w", err)
+	}
+	err = gob.NewEncoder(tmp).Encode(cp)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("saving checkpoint: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package stream
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mariomac/gostream/clock"
+	"github.com/mariomac/gostream/item"
+)
+
+// checkpointedTotals defines a pipeline that sums the sales of each product, checkpointing
+// its progress into the provided path
+func checkpointedTotals(t *testing.T, path string) Stream[kv[int]] {
+	t.Helper()
+	cp, err := NewCheckpointer(path, 0)
+	require.NoError(t, err)
+	totals := NewMemoryStore[string, int]()
+	require.NoError(t, CheckpointState[string, int](cp, "totals", totals, nil))
+	return ScanByKey(CheckpointedSlice(cp, sales.ToSlice()), totals, 0, item.Add[int])
+}
+
+func TestCheckpointedSlice(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint")
+	// the first run stops after three elements, so the last checkpoint only covers two
+	assert.Equal(t, []kv[int]{{Key: "apple", Val: 3}, {Key: "pear", Val: 1}, {Key: "apple", Val: 8}},
+		checkpointedTotals(t, path).Limit(3).ToSlice())
+
+	// the same pipeline resumes from the checkpoint, with the totals it recorded
+	assert.Equal(t, []kv[int]{{Key: "apple", Val: 8}, {Key: "plum", Val: 2}, {Key: "pear", Val: 5}},
+		checkpointedTotals(t, path).ToSlice())
+
+	// after the source ends, there is nothing left to resume
+	assert.Empty(t, checkpointedTotals(t, path).ToSlice())
+}
+
+func TestCheckpointState_ScanWith(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint")
+	runningTotal := func() Stream[int] {
+		cp, err := NewCheckpointer(path, 0)
+		require.NoError(t, err)
+		total := NewMemoryStore[struct{}, int]()
+		require.NoError(t, CheckpointState[struct{}, int](cp, "total", total, nil))
+		return ScanWith(CheckpointedSlice(cp, []int{1, 2, 3, 4, 5}), total, 0, item.Add[int])
+	}
+	assert.Equal(t, []int{1, 3, 6}, runningTotal().Limit(3).ToSlice())
+	// the last checkpoint covers two elements, so the total resumes from 3
+	assert.Equal(t, []int{6, 10, 15}, runningTotal().ToSlice())
+}
+
+func TestCheckpointState_AggregateByKeyWith(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint")
+	totals := func(crashOn string) []kv[int] {
+		cp, err := NewCheckpointer(path, 0)
+		require.NoError(t, err)
+		totals := NewMemoryStore[string, int]()
+		require.NoError(t, CheckpointState[string, int](cp, "totals", totals, nil))
+		src := Map(CheckpointedSlice(cp, sales.ToSlice()), func(p kv[int]) kv[int] {
+			if p.Key == crashOn {
+				panic("crash")
+			}
+			return p
+		})
+		return AggregateByKeyWith(src, totals, 0, item.Add[int]).ToSlice()
+	}
+	assert.Panics(t, func() {
+		totals("plum")
+	})
+	// the aggregation resumes after the three elements before the crash
+	assert.Equal(t, []kv[int]{{Key: "plum", Val: 2}, {Key: "pear", Val: 5}, {Key: "apple", Val: 8}},
+		totals(""))
+}
+
+func TestCheckpointedIterate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint")
+	powers := func() Stream[int] {
+		cp, err := NewCheckpointer(path, 0)
+		require.NoError(t, err)
+		return CheckpointedIterate(cp, 1, func(n int) int { return n * 2 }, nil)
+	}
+	assert.Equal(t, []int{1, 2, 4}, powers().Limit(3).ToSlice())
+	assert.Equal(t, []int{4, 8, 16, 32}, powers().Limit(4).ToSlice())
+}
+
+func TestCheckpointedChannel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint")
+	type visit struct {
+		offset int64
+		user   string
+	}
+	newUsers := func(visits ...visit) []string {
+		cp, err := NewCheckpointer(path, 0)
+		require.NoError(t, err)
+		seen := NewMemoryStore[string, bool]()
+		require.NoError(t, CheckpointState[string, bool](cp, "seen", seen, nil))
+		ch := make(chan visit, len(visits))
+		for _, v := range visits {
+			ch <- v
+		}
+		close(ch)
+		users := Map(CheckpointedChannel(cp, ch, func(v visit) int64 { return v.offset }),
+			func(v visit) string { return v.user })
+		return DistinctWith(users, seen).ToSlice()
+	}
+	cp, err := NewCheckpointer(path, 0)
+	require.NoError(t, err)
+	_, ok := cp.ChannelOffset()
+	assert.False(t, ok)
+
+	assert.Equal(t, []string{"ann", "bob"}, newUsers(visit{1, "ann"}, visit{2, "bob"}, visit{3, "ann"}))
+
+	cp, err = NewCheckpointer(path, 0)
+	require.NoError(t, err)
+	offset, ok := cp.ChannelOffset()
+	assert.True(t, ok)
+	assert.EqualValues(t, 3, offset)
+
+	// the producer sends again some processed elements, which are discarded
+	assert.Equal(t, []string{"cid"},
+		newUsers(visit{2, "bob"}, visit{3, "ann"}, visit{4, "cid"}, visit{5, "bob"}))
+}
+
+func TestCheckpointer_Interval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint")
+	clk := clock.NewManual(time.Now())
+	cp, err := NewCheckpointer(path, time.Second, WithCheckpointClock(clk))
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3},
+		CheckpointedSlice(cp, []int{0, 1, 2, 3, 4, 5}).Peek(func(n int) {
+			if n == 1 {
+				clk.Advance(time.Second)
+			}
+		}).Limit(4).ToSlice())
+
+	// the only checkpoint was taken when the third element was pulled, one second after the first
+	cp, err = NewCheckpointer(path, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 3, 4, 5}, CheckpointedSlice(cp, []int{0, 1, 2, 3, 4, 5}).ToSlice())
+}
+
+func TestCheckpointer_Errors(t *testing.T) {
+	dir := t.TempDir()
+	cp, err := NewCheckpointer(filepath.Join(dir, "nonexistent", "checkpoint"), 0)
+	require.NoError(t, err)
+	require.NoError(t, CheckpointState[string, int](cp, "totals", NewMemoryStore[string, int](), nil))
+	assert.Error(t, CheckpointState[string, int](cp, "totals", NewMemoryStore[string, int](), nil))
+
+	_, err = CheckpointedSlice(cp, []int{1, 2, 3}).ToSliceErr()
+	assert.Error(t, err)
+	assert.Panics(t, func() {
+		CheckpointedSlice(cp, []int{1, 2, 3})
+	})
+
+	corrupt := filepath.Join(dir, "corrupt")
+	require.NoError(t, os.WriteFile(corrupt, []byte("corrupt"), 0o600))
+	_, err = NewCheckpointer(corrupt, 0)
+	assert.Error(t, err)
+
+	assert.Panics(t, func() {
+		_, _ = NewCheckpointer(filepath.Join(dir, "checkpoint"), -time.Second)
+	})
+}
@@ -0,0 +1,61 @@
+package stream
+
+import (
+	"encoding/gob"
+	"io"
+)
+
+// Codec creates the encoders and decoders that the disk-backed operations (e.g. ExternalSorted)
+// use to write the elements of a stream to temporary files, and read them back. It is also
+// used to persist the entries of a StateStore (see OpenFileStore and SnapshotStore).
+type Codec[T any] interface {
+	// NewEncoder returns an Encoder that writes the encoded elements to w.
+	NewEncoder(w io.Writer) Encoder[T]
+	// NewDecoder returns a Decoder that reads the elements that an Encoder of the same
+	// Codec wrote into r.
+	NewDecoder(r io.Reader) Decoder[T]
+}
+
+// Encoder writes the encoded representation of elements to an underlying io.Writer.
+type Encoder[T any] interface {
+	Encode(n T) error
+}
+
+// Decoder reads and decodes elements from an underlying io.Reader. Decode must return
+// io.EOF when there are no more elements to decode.
+type Decoder[T any] interface {
+	Decode(n *T) error
+}
+
+// GobCodec returns a Codec that encodes the elements with the encoding/gob package.
+// As with any gob encoding, the element type must only contain exported fields, and
+// interface values require their concrete types to be registered with gob.Register.
+func GobCodec[T any]() Codec[T] {
+	return gobCodec[T]{}
+}
+
+type gobCodec[T any] struct{}
+
+func (gobCodec[T]) NewEncoder(w io.Writer) Encoder[T] {
+	return gobEncoder[T]{enc: gob.NewEncoder(w)}
+}
+
+func (gobCodec[T]) NewDecoder(r io.Reader) Decoder[T] {
+	return gobDecoder[T]{dec: gob.NewDecoder(r)}
+}
+
+type gobEncoder[T any] struct {
+	enc *gob.Encoder
+}
+
+func (g gobEncoder[T]) Encode(n T) error {
+	return g.enc.Encode(&n)
+}
+
+type gobDecoder[T any] struct {
+	dec *gob.Decoder
+}
+
+func (g gobDecoder[T]) Decode(n *T) error {
+	return g.dec.Decode(n)
+}
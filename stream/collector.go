@@ -0,0 +1,32 @@
+package stream
+
+// Collector is a mutable reduction operation that accumulates the elements of a stream
+// into a result container of type A, and transforms the accumulated container into a
+// final result of type R.
+// The collectors package provides implementations of common reduction operations.
+type Collector[T, A, R any] struct {
+	// Supplier creates a new, empty result container.
+	Supplier func() A
+	// Accumulator incorporates an element into a result container, and returns the container.
+	// Returning the container allows using value types (e.g. an int for counting) as
+	// result containers.
+	Accumulator func(A, T) A
+	// Combiner merges two partial result containers into a single one, and returns it.
+	// It allows processing different parts of the stream separately (e.g. in parallel).
+	Combiner func(A, A) A
+	// Finisher transforms the result container into the final result.
+	Finisher func(A) R
+}
+
+// Collect performs a mutable reduction operation on the elements of the input
+// stream using the provided Collector.
+func Collect[T, A, R any](input Stream[T], collector Collector[T, A, R]) R {
+	assertFinite(input)
+	next, stop := pull(input)
+	defer stop()
+	container := collector.Supplier()
+	for n, ok := next(); ok; n, ok = next() {
+		container = collector.Accumulator(container, n)
+	}
+	return collector.Finisher(container)
+}
@@ -0,0 +1,31 @@
+package stream
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollect(t *testing.T) {
+	upperJoin := Collector[string, *strings.Builder, string]{
+		Supplier: func() *strings.Builder {
+			return &strings.Builder{}
+		},
+		Accumulator: func(sb *strings.Builder, s string) *strings.Builder {
+			sb.WriteString(strings.ToUpper(s))
+			return sb
+		},
+		Combiner: func(a, b *strings.Builder) *strings.Builder {
+			a.WriteString(b.String())
+			return a
+		},
+		Finisher: (*strings.Builder).String,
+	}
+	assert.Equal(t, "HELLOWORLD", Collect(Of("hello", "world"), upperJoin))
+	assert.Empty(t, Collect(Empty[string](), upperJoin))
+
+	closed := false
+	Collect(Of("a").OnClose(func() { closed = true }), upperJoin)
+	assert.True(t, closed)
+}
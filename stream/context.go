@@ -0,0 +1,59 @@
+package stream
+
+import (
+	"context"
+)
+
+// WithContext returns an equivalent stream that is bound to the provided context.
+// When the context is done, the stream stops pulling items from its source, even if it
+// is blocked waiting for a channel, and the terminal operation finishes with the items
+// that had been already processed.
+// This function is equivalent to invoking input.WithContext(ctx) as method.
+func WithContext[T any](input Stream[T], ctx context.Context) Stream[T] {
+	return input.WithContext(ctx)
+}
+
+func (is *iterableStream[T]) WithContext(ctx context.Context) Stream[T] {
+	props := is.props
+	props.ctx = ctx
+	return &iterableStream[T]{infinite: is.infinite, props: props, supply: is.supply}
+}
+
+// ForEachContext invokes the consumer function for each item of the Stream, until the stream
+// ends or the provided context is done. In the latter case, it returns the ctx.Err() value.
+// If the stream has been bound to another context through the WithContext method, the
+// iteration also stops when that context is done, and its error is returned.
+func ForEachContext[T any](ctx context.Context, input Stream[T], consumer func(T)) error {
+	return forEachErr(ctx, input, func(n T) error {
+		consumer(n)
+		return nil
+	})
+}
+
+// ToSliceContext returns a Slice Containing all the elements of this Stream, or the
+// ctx.Err() value if the provided context is done before the stream ends. In that case,
+// the returned slice contains the elements that had been processed until then.
+// If the stream has been bound to another context through the WithContext method, the
+// iteration also stops when that context is done, and its error is returned.
+func ToSliceContext[T any](ctx context.Context, input Stream[T]) ([]T, error) {
+	assertFinite(input)
+	var out []T
+	err := ForEachContext(ctx, input, func(n T) {
+		out = append(out, n)
+	})
+	return out, err
+}
+
+// CountContext returns the count of elements in this stream, or the ctx.Err() value if
+// the provided context is done before the stream ends. In that case, the returned count
+// is the number of elements that had been processed until then.
+// If the stream has been bound to another context through the WithContext method, the
+// iteration also stops when that context is done, and its error is returned.
+func CountContext[T any](ctx context.Context, input Stream[T]) (int, error) {
+	assertFinite(input)
+	count := 0
+	err := ForEachContext(ctx, input, func(T) {
+		count++
+	})
+	return count, err
+}
@@ -0,0 +1,83 @@
+package stream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mariomac/gostream/item"
+)
+
+func TestToSliceContext_ReleasesBlockedChannel(t *testing.T) {
+	src := make(chan int, 3)
+	src <- 1
+	src <- 2
+	src <- 3
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	out, err := ToSliceContext(ctx, OfChannel(src).Map(item.Neg[int]))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, []int{-1, -2, -3}, out)
+}
+
+func TestToSliceContext_NotCancelled(t *testing.T) {
+	out, err := ToSliceContext(context.Background(), Of(1, 2, 3))
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, out)
+}
+
+func TestCountContext_SlowFilter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	count := 0
+	// the filter never accepts any element, so the Limit would never be reached
+	cnt, err := CountContext(ctx, Generate(func() int {
+		count++
+		if count == 10 {
+			cancel()
+		}
+		return count
+	}).Filter(item.IsZero[int]).Limit(10))
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, cnt)
+	assert.Equal(t, 10, count)
+}
+
+func TestWithContext(t *testing.T) {
+	src := make(chan int)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		src <- 1
+		src <- 2
+		cancel()
+	}()
+	var consumed []int
+	// the context is bound to the stream, so the regular terminals also stop
+	OfChannel(src).WithContext(ctx).ForEach(func(n int) {
+		consumed = append(consumed, n)
+	})
+	assert.Equal(t, []int{1, 2}, consumed)
+
+	err := ForEachContext(context.Background(), OfChannel(src).WithContext(ctx), func(int) {})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestForEachContext_Parallel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	src := make(chan int)
+	go func() {
+		for i := 1; i <= 5; i++ {
+			src <- i
+		}
+		cancel()
+	}()
+	sum := 0
+	err := ForEachContext(ctx, OfChannel(src).Parallel(3).Map(item.Neg[int]), func(n int) {
+		sum += n
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.GreaterOrEqual(t, sum, -15)
+}
@@ -0,0 +1,191 @@
+package stream
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// failures records the errors reported by the fallible operations of an execution
+type failures struct {
+	// cancel the execution, using the reported error as cause
+	cancel          context.CancelCauseFunc
+	continueOnError bool
+
+	mu   sync.Mutex
+	errs []error
+}
+
+// reported returns the errors that have been reported when continueOnError is true
+func (f *failures) reported() []error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.errs)
+}
+
+// fail reports an error from a fallible operation. It returns true if the operation
+// can keep processing the stream, or false if the execution has been cancelled.
+func (ex *execution) fail(err error) bool {
+	if !ex.failures.continueOnError {
+		ex.failures.cancel(err)
+		return false
+	}
+	ex.failures.mu.Lock()
+	ex.failures.errs = append(ex.failures.errs, err)
+	ex.failures.mu.Unlock()
+	return true
+}
+
+// ContinueOnError returns an equivalent stream whose fallible operations (e.g. MapErr, FilterErr
+// or ForEachErr) do not stop the stream when they return an error. Instead, the failing items
+// are discarded and the error-returning terminal operations return all the errors joined with
+// errors.Join.
+// This function is equivalent to invoking input.ContinueOnError() as method.
+func ContinueOnError[T any](input Stream[T]) Stream[T] {
+	return input.ContinueOnError()
+}
+
+func (is *iterableStream[T]) ContinueOnError() Stream[T] {
+	props := is.props
+	props.continueOnError = true
+	return &iterableStream[T]{infinite: is.infinite, props: props, supply: is.supply}
+}
+
+// MapErr returns a Stream consisting of the results of individually applying the
+// fallible mapper function to each element of the input Stream.
+// If the mapper returns an error, the stream stops, and the error is returned by the
+// error-returning terminal operations (e.g. ToSliceErr or ForEachErr). If the stream
+// has been marked with ContinueOnError, the failing element is discarded and the
+// stream keeps processing the rest of elements.
+func MapErr[IT, OT any](input Stream[IT], mapper func(IT) (OT, error)) Stream[OT] {
+	if input.properties().parallel() {
+		return parallelStage(input, input.isInfinite(), func(ex *execution, n IT) []OT {
+			out, err := mapper(n)
+			if err != nil {
+				ex.fail(err)
+				return nil
+			}
+			return []OT{out}
+		})
+	}
+	return &iterableStream[OT]{
+		infinite: input.isInfinite(),
+		props:    input.properties(),
+		supply: func(ex *execution) iterator[OT] {
+			next := input.iterator(ex)
+			return func() (OT, bool) {
+				for {
+					n, ok := next()
+					if !ok {
+						return finishedIterator[OT]()
+					}
+					out, err := mapper(n)
+					if err == nil {
+						return out, true
+					}
+					if !ex.fail(err) {
+						return finishedIterator[OT]()
+					}
+				}
+			}
+		},
+	}
+}
+
+// FilterErr returns a Stream consisting of the items of this stream that match the given
+// fallible predicate.
+// If the predicate returns an error, the stream stops, and the error is returned by the
+// error-returning terminal operations (e.g. ToSliceErr or ForEachErr). If the stream
+// has been marked with ContinueOnError, the failing element is discarded and the
+// stream keeps processing the rest of elements.
+// This function is equivalent to invoking input.FilterErr(predicate) as method.
+func FilterErr[T any](input Stream[T], predicate func(T) (bool, error)) Stream[T] {
+	return input.FilterErr(predicate)
+}
+
+func (is *iterableStream[T]) FilterErr(predicate func(T) (bool, error)) Stream[T] {
+	if is.props.parallel() {
+		return parallelStage[T, T](is, is.infinite, func(ex *execution, n T) []T {
+			match, err := predicate(n)
+			if err != nil {
+				ex.fail(err)
+				return nil
+			}
+			if match {
+				return []T{n}
+			}
+			return nil
+		})
+	}
+	return &iterableStream[T]{
+		infinite: is.infinite,
+		props:    is.props,
+		supply: func(ex *execution) iterator[T] {
+			next := is.iterator(ex)
+			return func() (T, bool) {
+				for {
+					n, ok := next()
+					if !ok {
+						return finishedIterator[T]()
+					}
+					match, err := predicate(n)
+					if err != nil {
+						if !ex.fail(err) {
+							return finishedIterator[T]()
+						}
+						continue
+					}
+					if match {
+						return n, true
+					}
+				}
+			}
+		},
+	}
+}
+
+// ForEachErr invokes the fallible consumer function for each item of the Stream.
+// It returns the first error returned by the consumer or any of the fallible operations
+// of the stream. If the stream has been marked with ContinueOnError, the stream is
+// processed until the end and all the errors are returned joined.
+// This function is equivalent to invoking input.ForEachErr(consumer) as method.
+func ForEachErr[T any](input Stream[T], consumer func(T) error) error {
+	return input.ForEachErr(consumer)
+}
+
+func (is *iterableStream[T]) ForEachErr(consumer func(T) error) error {
+	return forEachErr[T](context.Background(), is, consumer)
+}
+
+// ToSliceErr returns a Slice containing all the elements of this Stream, along with the
+// first error returned by any of the fallible operations of the stream. In that case, the
+// returned slice contains the elements that had been processed until then.
+// If the stream has been marked with ContinueOnError, it returns all the successfully processed
+// elements, and all the errors joined.
+// This function is equivalent to invoking input.ToSliceErr() as method.
+func ToSliceErr[T any](input Stream[T]) ([]T, error) {
+	return input.ToSliceErr()
+}
+
+func (is *iterableStream[T]) ToSliceErr() ([]T, error) {
+	assertFinite[T](is)
+	var out []T
+	err := forEachErr[T](context.Background(), is, func(n T) error {
+		out = append(out, n)
+		return nil
+	})
+	return out, err
+}
+
+// forEachErr iterates the input stream within an execution bound to the provided context
+func forEachErr[T any](ctx context.Context, input Stream[T], consumer func(T) error) error {
+	ex, stop := newExecution(ctx, input.properties())
+	defer stop()
+	next := input.iterator(ex)
+	for in, ok := next(); ok; in, ok = next() {
+		if err := consumer(in); err != nil && !ex.fail(err) {
+			break
+		}
+	}
+	return ex.err()
+}
@@ -0,0 +1,97 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapErr(t *testing.T) {
+	parsed, err := MapErr(Of("1", "2", "3"), strconv.Atoi).ToSliceErr()
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, parsed)
+}
+
+func TestMapErr_StopsOnFirstError(t *testing.T) {
+	var mapped []string
+	parsed, err := MapErr(Of("1", "a", "3", "b"), func(s string) (int, error) {
+		mapped = append(mapped, s)
+		return strconv.Atoi(s)
+	}).ToSliceErr()
+	var numErr *strconv.NumError
+	require.ErrorAs(t, err, &numErr)
+	assert.Equal(t, "a", numErr.Num)
+	assert.Equal(t, []int{1}, parsed)
+	assert.Equal(t, []string{"1", "a"}, mapped)
+}
+
+func TestMapErr_ContinueOnError(t *testing.T) {
+	parsed, err := MapErr(Of("1", "a", "3", "b").ContinueOnError(), strconv.Atoi).ToSliceErr()
+	assert.Equal(t, []int{1, 3}, parsed)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"a"`)
+	assert.Contains(t, err.Error(), `"b"`)
+}
+
+func TestFilterErr(t *testing.T) {
+	errNegative := errors.New("negative")
+	isEven := func(n int) (bool, error) {
+		if n < 0 {
+			return false, errNegative
+		}
+		return n%2 == 0, nil
+	}
+
+	out, err := Of(1, 2, 3, 4).FilterErr(isEven).ToSliceErr()
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 4}, out)
+
+	out, err = Of(1, 2, -3, 4).FilterErr(isEven).ToSliceErr()
+	assert.Same(t, errNegative, err)
+	assert.Equal(t, []int{2}, out)
+
+	out, err = FilterErr(Of(-1, 2, -3, 4).ContinueOnError(), isEven).ToSliceErr()
+	assert.ErrorIs(t, err, errNegative)
+	assert.Equal(t, []int{2, 4}, out)
+}
+
+func TestForEachErr(t *testing.T) {
+	var consumed []int
+	err := Of(1, 2, 3, 4).ForEachErr(func(n int) error {
+		if n == 3 {
+			return fmt.Errorf("can't consume %d", n)
+		}
+		consumed = append(consumed, n)
+		return nil
+	})
+	assert.EqualError(t, err, "can't consume 3")
+	assert.Equal(t, []int{1, 2}, consumed)
+
+	consumed = nil
+	err = ForEachErr(Of(1, 2, 3, 4).ContinueOnError(), func(n int) error {
+		if n%2 == 1 {
+			return fmt.Errorf("can't consume %d", n)
+		}
+		consumed = append(consumed, n)
+		return nil
+	})
+	assert.EqualError(t, err, "can't consume 1\ncan't consume 3")
+	assert.Equal(t, []int{2, 4}, consumed)
+}
+
+func TestMapErr_Parallel(t *testing.T) {
+	input := Map(Iterate(1, func(n int) int { return n + 1 }).Limit(100),
+		strconv.Itoa)
+
+	parsed, err := MapErr(input.Parallel(4), strconv.Atoi).ToSliceErr()
+	require.NoError(t, err)
+	require.Len(t, parsed, 100)
+	assert.Equal(t, 100, parsed[99])
+
+	_, err = MapErr(Concat(input, Of("x")).Parallel(4), strconv.Atoi).ToSliceErr()
+	assert.ErrorIs(t, err, strconv.ErrSyntax)
+}
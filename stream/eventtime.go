@@ -0,0 +1,132 @@
+package stream
+
+import (
+	"fmt"
+	"time"
+)
+
+// WithEventTime returns an equivalent stream whose event-time operations read the event time
+// of each element with the provided timestamp function, and track the progress of the event
+// time with the watermarks of the provided strategy.
+// The event time is kept by the subsequent operations that do not transform the elements (e.g.
+// Filter or Limit). After transforming the elements (e.g. with Map), WithEventTime must be
+// invoked again before any event-time operation.
+// This function is equivalent to invoking input.WithEventTime(timestamp, watermarks) as method.
+func WithEventTime[T any](input Stream[T], timestamp func(T) time.Time, watermarks WatermarkStrategy) Stream[T] {
+	return input.WithEventTime(timestamp, watermarks)
+}
+
+func (is *iterableStream[T]) WithEventTime(timestamp func(T) time.Time, watermarks WatermarkStrategy) Stream[T] {
+	props := is.props
+	props.eventTime = eventTime[T]{timestamp: timestamp, watermarks: watermarks}
+	return &iterableStream[T]{infinite: is.infinite, props: props, supply: is.supply}
+}
+
+// WatermarkStrategy creates a WatermarkGenerator for each iteration of an event-time stream.
+type WatermarkStrategy func() WatermarkGenerator
+
+// WatermarkGenerator tracks the watermark of an event-time stream: the event time until which
+// the stream is considered complete, so no more elements with an earlier event time are
+// expected. The elements whose event time is before the watermark are late.
+type WatermarkGenerator interface {
+	// OnEvent is invoked with the event time of each element of the stream, in order of
+	// arrival, and returns the watermark after that element. The watermark must never decrease.
+	OnEvent(ts time.Time) time.Time
+}
+
+// BoundedOutOfOrderness returns a WatermarkStrategy for streams whose elements can arrive out
+// of order, but no later than maxDelay (in event time) after the elements that follow them.
+// The watermark is the latest event time that has been seen, minus maxDelay.
+// It panics if maxDelay is negative.
+func BoundedOutOfOrderness(maxDelay time.Duration) WatermarkStrategy {
+	if maxDelay < 0 {
+		panic(fmt.Sprintf("out-of-orderness delay can't be negative. Got: %v", maxDelay))
+	}
+	return func() WatermarkGenerator {
+		return &boundedOutOfOrderness{maxDelay: maxDelay}
+	}
+}
+
+// AscendingTimestamps returns a WatermarkStrategy for streams whose elements arrive in order
+// of event time. The watermark is the latest event time that has been seen, so any element
+// with an earlier event time is late.
+func AscendingTimestamps() WatermarkStrategy {
+	return BoundedOutOfOrderness(0)
+}
+
+type boundedOutOfOrderness struct {
+	maxDelay  time.Duration
+	watermark time.Time
+}
+
+func (b *boundedOutOfOrderness) OnEvent(ts time.Time) time.Time {
+	if wm := ts.Add(-b.maxDelay); wm.After(b.watermark) {
+		b.watermark = wm
+	}
+	return b.watermark
+}
+
+// endOfTime is the watermark of an event-time stream that has ended: no more elements
+// are expected.
+var endOfTime = time.Unix(1<<62, 0)
+
+// eventTime is attached to the properties of a stream by WithEventTime
+type eventTime[T any] struct {
+	timestamp  func(T) time.Time
+	watermarks WatermarkStrategy
+}
+
+// eventTimeOf returns the event time attached to the input stream, or panics if the
+// stream has no event time for its elements.
+func eventTimeOf[T any](input Stream[T]) eventTime[T] {
+	et, ok := input.properties().eventTime.(eventTime[T])
+	if !ok {
+		var v T
+		panic(fmt.Sprintf("event-time operation in a Stream[%T] without event time."+
+			" Invoke WithEventTime before", v))
+	}
+	return et
+}
+
+// eventTracker tracks the watermark of an iteration of an event-time stream
+type eventTracker[T any] struct {
+	timestamp func(T) time.Time
+	generator WatermarkGenerator
+	watermark time.Time
+}
+
+func newEventTracker[T any](et eventTime[T]) *eventTracker[T] {
+	return &eventTracker[T]{timestamp: et.timestamp, generator: et.watermarks()}
+}
+
+// observe returns the event time of the provided element, and whether it is late because it
+// is before the current watermark. Then it advances the watermark.
+func (e *eventTracker[T]) observe(n T) (ts time.Time, late bool) {
+	ts = e.timestamp(n)
+	late = ts.Before(e.watermark)
+	if wm := e.generator.OnEvent(ts); wm.After(e.watermark) {
+		e.watermark = wm
+	}
+	return ts, late
+}
+
+// end sets the watermark to the end of time, after the stream ends
+func (e *eventTracker[T]) end() {
+	e.watermark = endOfTime
+}
+
+// withSide is an element of either the main output or the side output of an operation
+type withSide[M, S any] struct {
+	main   M
+	side   S
+	isSide bool
+}
+
+// splitSide returns two streams with the elements of the main output and the side output of
+// the input stream. Both streams share a single iteration of the input stream, in the same way
+// as the streams returned by UnzipStreams.
+func splitSide[M, S any](input Stream[withSide[M, S]]) (Stream[M], Stream[S]) {
+	u := &unzipper[withSide[M, S]]{input: input}
+	return unzipStream(u, &u.a, &u.b, func(o withSide[M, S]) (M, bool) { return o.main, !o.isSide }),
+		unzipStream(u, &u.b, &u.a, func(o withSide[M, S]) (S, bool) { return o.side, o.isSide })
+}
@@ -0,0 +1,68 @@
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// event is a test element whose event time is a number of seconds since the epoch
+type event struct {
+	sec  int
+	name string
+}
+
+func atSecond(sec int) time.Time {
+	return epoch.Add(time.Duration(sec) * time.Second)
+}
+
+func eventTimeOfPair(p kv[event]) time.Time {
+	return atSecond(p.Val.sec)
+}
+
+func TestBoundedOutOfOrderness(t *testing.T) {
+	gen := BoundedOutOfOrderness(2 * time.Second)()
+	assert.Equal(t, atSecond(3), gen.OnEvent(atSecond(5)))
+	// the watermark never decreases
+	assert.Equal(t, atSecond(3), gen.OnEvent(atSecond(4)))
+	assert.Equal(t, atSecond(8), gen.OnEvent(atSecond(10)))
+
+	// each iteration gets its own generator
+	assert.Equal(t, atSecond(1), BoundedOutOfOrderness(2*time.Second)().OnEvent(atSecond(3)))
+
+	asc := AscendingTimestamps()()
+	assert.Equal(t, atSecond(3), asc.OnEvent(atSecond(3)))
+
+	assert.Panics(t, func() {
+		BoundedOutOfOrderness(-time.Second)
+	})
+}
+
+func TestEventTracker(t *testing.T) {
+	tracker := newEventTracker(eventTimeOf(
+		Of(event{}).WithEventTime(func(e event) time.Time { return atSecond(e.sec) }, BoundedOutOfOrderness(time.Second))))
+	ts, late := tracker.observe(event{sec: 5})
+	assert.Equal(t, atSecond(5), ts)
+	assert.False(t, late)
+	_, late = tracker.observe(event{sec: 4})
+	assert.False(t, late)
+	_, late = tracker.observe(event{sec: 3})
+	assert.True(t, late)
+	assert.Equal(t, atSecond(4), tracker.watermark)
+}
+
+func TestWithEventTime_Missing(t *testing.T) {
+	assert.Panics(t, func() {
+		eventTimeOf(Of(1, 2, 3))
+	})
+	// the event time is kept by the operations that do not change the type of the elements
+	assert.NotPanics(t, func() {
+		eventTimeOf(Of(1, 2, 3).WithEventTime(func(n int) time.Time { return atSecond(n) }, AscendingTimestamps()).
+			Filter(func(n int) bool { return n > 1 }).Limit(1))
+	})
+	assert.Panics(t, func() {
+		eventTimeOf(Map(Of(1, 2, 3).WithEventTime(func(n int) time.Time { return atSecond(n) }, AscendingTimestamps()),
+			func(n int) string { return "" }))
+	})
+}
@@ -0,0 +1,286 @@
+package stream
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// EventWindow is a window of elements of an event-time stream.
+type EventWindow[T any] struct {
+	// Start and End of the window, in event time. The window contains the elements whose
+	// event time is at or after Start, and before End.
+	Start, End time.Time
+	// Elements of the window, in order of arrival.
+	Elements []T
+	// Refired is true if the window had been already emitted, and it is emitted again
+	// because it got late elements (see RefireLate).
+	Refired bool
+}
+
+// WindowAssigner defines the event-time windows that each element belongs to.
+// It is created with TumblingEventWindows, SlidingEventWindows or SessionEventWindows.
+type WindowAssigner struct {
+	size, slide time.Duration
+	// gap is only set for session windows
+	gap time.Duration
+}
+
+// TumblingEventWindows returns a WindowAssigner of adjacent, non-overlapping windows of the
+// provided size, aligned to multiples of size since the zero time.
+// It panics if size is not positive.
+func TumblingEventWindows(size time.Duration) WindowAssigner {
+	return SlidingEventWindows(size, size)
+}
+
+// SlidingEventWindows returns a WindowAssigner of windows of the provided size, where each
+// window starts slide time after the start of the previous window. If slide is lower than
+// size, windows overlap and an element can belong to multiple windows.
+// Windows are aligned to multiples of slide since the zero time.
+// It panics if size or slide are not positive.
+func SlidingEventWindows(size, slide time.Duration) WindowAssigner {
+	if size <= 0 || slide <= 0 {
+		panic(fmt.Sprintf("window size and slide must be positive. Got: %v and %v", size, slide))
+	}
+	return WindowAssigner{size: size, slide: slide}
+}
+
+// SessionEventWindows returns a WindowAssigner of session windows: each session groups the
+// elements whose event times are less than gap apart, and ends gap after the event time of
+// its last element. An element that fills the gap between two sessions merges them.
+// It panics if gap is not positive.
+func SessionEventWindows(gap time.Duration) WindowAssigner {
+	if gap <= 0 {
+		panic(fmt.Sprintf("session gap must be positive. Got: %v", gap))
+	}
+	return WindowAssigner{gap: gap}
+}
+
+// span is the start and end of a window
+type span struct {
+	start, end time.Time
+}
+
+// assign returns the windows of an element with the provided event time
+func (wa WindowAssigner) assign(ts time.Time) []span {
+	if wa.gap > 0 {
+		return []span{{start: ts, end: ts.Add(wa.gap)}}
+	}
+	var spans []span
+	for start := ts.Truncate(wa.slide); start.Add(wa.size).After(ts); start = start.Add(-wa.slide) {
+		spans = append(spans, span{start: start, end: start.Add(wa.size)})
+	}
+	return spans
+}
+
+// LatePolicy defines what event-time windows do with late elements: those that arrive when
+// the watermark has already passed the end of a window that they belong to.
+type LatePolicy int
+
+const (
+	// DropLate discards the late elements.
+	DropLate LatePolicy = iota
+	// SideOutputLate emits the late elements through the late stream of the windows. An
+	// element that is late for some of its windows but not for others (e.g. with sliding
+	// windows) is only added to the latter, and not emitted through the late stream.
+	SideOutputLate
+	// RefireLate adds the late elements to their windows, which are emitted again, as long
+	// as the watermark has not passed the end of the window plus the allowed lateness.
+	// Later elements are discarded.
+	RefireLate
+)
+
+// Lateness configures how event-time windows handle the late elements.
+// The zero value drops all the late elements.
+type Lateness struct {
+	// Policy for the late elements.
+	Policy LatePolicy
+	// Allowed is how long, in event time, the windows are kept after the watermark passes
+	// their end, so they can be emitted again with late elements. It is only used by the
+	// RefireLate policy.
+	Allowed time.Duration
+}
+
+// EventTimeWindows returns a stream of the windows of the input event-time stream, as defined
+// by the provided WindowAssigner. The input stream must have an event time attached with
+// WithEventTime.
+// Each window is emitted as soon as the watermark passes its end, so the emitted windows only
+// depend on the event time of the elements and the watermark strategy, and not on when the
+// elements arrive. Then, replaying historical data with OfSlice returns the same windows as
+// receiving it live with OfChannel. Windows without elements are not emitted.
+// When the input stream ends, the windows that are still open are emitted immediately.
+// The late elements are handled as defined by the provided Lateness. The late stream only
+// contains elements if its policy is SideOutputLate. Both returned streams share a single
+// iteration of the input stream, in the same way as the streams returned by UnzipStreams, so
+// if the late elements are not needed, the late stream must be closed before iterating the
+// windows stream.
+// It panics if the input stream has no event time.
+func EventTimeWindows[T any](
+	input Stream[T], assigner WindowAssigner, lateness Lateness,
+) (windows Stream[EventWindow[T]], late Stream[T]) {
+	return splitSide(eventTimeWindows(input, assigner, lateness))
+}
+
+func eventTimeWindows[T any](
+	input Stream[T], assigner WindowAssigner, lateness Lateness,
+) Stream[withSide[EventWindow[T], T]] {
+	et := eventTimeOf(input)
+	return &iterableStream[withSide[EventWindow[T], T]]{
+		infinite: input.isInfinite(),
+		props:    input.properties(),
+		supply: func(ex *execution) iterator[withSide[EventWindow[T], T]] {
+			next := input.iterator(ex.ordered())
+			tracker := newEventTracker(et)
+			w := &eventWindows[T]{assigner: assigner, lateness: lateness}
+			var pending []withSide[EventWindow[T], T]
+			ended := false
+			return func() (withSide[EventWindow[T], T], bool) {
+				for len(pending) == 0 {
+					if ended {
+						return finishedIterator[withSide[EventWindow[T], T]]()
+					}
+					n, ok := next()
+					if !ok {
+						ended = true
+						tracker.end()
+					} else {
+						watermark := tracker.watermark
+						ts, _ := tracker.observe(n)
+						if !w.add(n, ts, watermark) && lateness.Policy == SideOutputLate {
+							pending = append(pending, withSide[EventWindow[T], T]{side: n, isSide: true})
+						}
+					}
+					for _, window := range w.fire(tracker.watermark) {
+						pending = append(pending, withSide[EventWindow[T], T]{main: window})
+					}
+				}
+				o := pending[0]
+				pending = pending[1:]
+				return o, true
+			}
+		},
+	}
+}
+
+// eventWindows keeps the event-time windows that can still get elements, sorted by start time
+type eventWindows[T any] struct {
+	assigner WindowAssigner
+	lateness Lateness
+	windows  []*eventWindow[T]
+	// arrivals counts the added elements, to keep them in order of arrival when sessions merge
+	arrivals int
+}
+
+type eventWindow[T any] struct {
+	span
+	elements []ranked[T]
+	// fired is true when the window has been emitted at least once
+	fired bool
+	// pending is true when the window has elements that have not been emitted yet
+	pending bool
+}
+
+// accepts returns whether a window with the provided end can still get elements,
+// given the current watermark
+func (w *eventWindows[T]) accepts(end, watermark time.Time) bool {
+	if w.lateness.Policy == RefireLate {
+		end = end.Add(w.lateness.Allowed)
+	}
+	return end.After(watermark)
+}
+
+// add the element with the provided event time to the windows that are not late for it, and
+// returns false if the element is late for all of them, so it has not been added to any window
+func (w *eventWindows[T]) add(n T, ts, watermark time.Time) bool {
+	if w.assigner.gap > 0 {
+		return w.addToSession(n, ts, watermark)
+	}
+	w.arrivals++
+	added := false
+	for _, s := range w.assigner.assign(ts) {
+		if !w.accepts(s.end, watermark) {
+			continue
+		}
+		added = true
+		idx, found := slices.BinarySearchFunc(w.windows, s.start, func(ew *eventWindow[T], start time.Time) int {
+			return ew.start.Compare(start)
+		})
+		if !found {
+			w.windows = slices.Insert(w.windows, idx, &eventWindow[T]{span: s})
+		}
+		w.windows[idx].elements = append(w.windows[idx].elements, ranked[T]{val: n, seq: w.arrivals})
+		w.windows[idx].pending = true
+	}
+	return added
+}
+
+// addToSession adds the element to a new session, merging it with the sessions it overlaps.
+// It returns false if the merged session is late.
+func (w *eventWindows[T]) addToSession(n T, ts, watermark time.Time) bool {
+	merged := &eventWindow[T]{span: span{start: ts, end: ts.Add(w.assigner.gap)}, pending: true}
+	// sessions don't overlap each other, so the sessions that overlap the new one are contiguous.
+	// A session that ends exactly when the new one starts (or vice versa) does not overlap it.
+	first := slices.IndexFunc(w.windows, func(ew *eventWindow[T]) bool {
+		return ew.end.After(merged.start)
+	})
+	if first < 0 {
+		first = len(w.windows)
+	}
+	last := first
+	for ; last < len(w.windows) && w.windows[last].start.Before(merged.end); last++ {
+		ew := w.windows[last]
+		merged.start = minTime(merged.start, ew.start)
+		merged.end = maxTime(merged.end, ew.end)
+		merged.elements = append(merged.elements, ew.elements...)
+		merged.fired = merged.fired || ew.fired
+	}
+	if !w.accepts(merged.end, watermark) {
+		return false
+	}
+	w.arrivals++
+	merged.elements = append(merged.elements, ranked[T]{val: n, seq: w.arrivals})
+	slices.SortFunc(merged.elements, func(a, b ranked[T]) int {
+		return cmp.Compare(a.seq, b.seq)
+	})
+	w.windows = slices.Replace(w.windows, first, last, merged)
+	return true
+}
+
+// fire returns the windows whose end has been passed by the watermark and that have elements
+// that were not emitted yet, sorted by end time. Then it removes the windows that can't get
+// more elements.
+func (w *eventWindows[T]) fire(watermark time.Time) []EventWindow[T] {
+	var fired []EventWindow[T]
+	for _, ew := range w.windows {
+		if ew.pending && !ew.end.After(watermark) {
+			elements := make([]T, 0, len(ew.elements))
+			for _, e := range ew.elements {
+				elements = append(elements, e.val)
+			}
+			fired = append(fired, EventWindow[T]{Start: ew.start, End: ew.end, Elements: elements, Refired: ew.fired})
+			ew.fired, ew.pending = true, false
+		}
+	}
+	slices.SortStableFunc(fired, func(a, b EventWindow[T]) int {
+		return cmp.Or(a.End.Compare(b.End), a.Start.Compare(b.Start))
+	})
+	w.windows = slices.DeleteFunc(w.windows, func(ew *eventWindow[T]) bool {
+		return !w.accepts(ew.end, watermark)
+	})
+	return fired
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,216 @@
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func eventTimeOfEvent(e event) time.Time {
+	return atSecond(e.sec)
+}
+
+func ev(name string, sec int) event {
+	return event{sec: sec, name: name}
+}
+
+func window(start, end int, elements ...event) EventWindow[event] {
+	return EventWindow[event]{Start: atSecond(start), End: atSecond(end), Elements: elements}
+}
+
+func refired(w EventWindow[event]) EventWindow[event] {
+	w.Refired = true
+	return w
+}
+
+// readings with out-of-order events
+var readings = []event{
+	ev("a", 1), ev("b", 5), ev("c", 12),
+	// late: the watermark is already at 11
+	ev("d", 9),
+	ev("e", 15), ev("f", 21),
+	// late: the watermark is already at 20
+	ev("g", 8),
+	ev("h", 22),
+}
+
+func collectEventWindows(input Stream[event], assigner WindowAssigner, lateness Lateness) ([]EventWindow[event], []event) {
+	windows, late := EventTimeWindows(
+		input.WithEventTime(eventTimeOfEvent, BoundedOutOfOrderness(time.Second)), assigner, lateness)
+	// the windows are consumed before the late elements, which are buffered meanwhile
+	return windows.ToSlice(), late.ToSlice()
+}
+
+func TestEventTimeWindows_Tumbling(t *testing.T) {
+	windows, late := collectEventWindows(OfSlice(readings), TumblingEventWindows(10*time.Second), Lateness{})
+	assert.Equal(t, []EventWindow[event]{
+		window(0, 10, ev("a", 1), ev("b", 5)),
+		window(10, 20, ev("c", 12), ev("e", 15)),
+		window(20, 30, ev("f", 21), ev("h", 22)),
+	}, windows)
+	assert.Empty(t, late)
+}
+
+func TestEventTimeWindows_SideOutputLate(t *testing.T) {
+	windows, late := collectEventWindows(OfSlice(readings), TumblingEventWindows(10*time.Second),
+		Lateness{Policy: SideOutputLate})
+	assert.Equal(t, []EventWindow[event]{
+		window(0, 10, ev("a", 1), ev("b", 5)),
+		window(10, 20, ev("c", 12), ev("e", 15)),
+		window(20, 30, ev("f", 21), ev("h", 22)),
+	}, windows)
+	assert.Equal(t, []event{ev("d", 9), ev("g", 8)}, late)
+}
+
+func TestEventTimeWindows_RefireLate(t *testing.T) {
+	windows, late := collectEventWindows(OfSlice(readings), TumblingEventWindows(10*time.Second),
+		Lateness{Policy: RefireLate, Allowed: 5 * time.Second})
+	assert.Equal(t, []EventWindow[event]{
+		window(0, 10, ev("a", 1), ev("b", 5)),
+		// d is within the allowed lateness, but g isn't
+		refired(window(0, 10, ev("a", 1), ev("b", 5), ev("d", 9))),
+		window(10, 20, ev("c", 12), ev("e", 15)),
+		window(20, 30, ev("f", 21), ev("h", 22)),
+	}, windows)
+	assert.Empty(t, late)
+}
+
+func TestEventTimeWindows_Sliding(t *testing.T) {
+	windows, _ := collectEventWindows(Of(ev("a", 1), ev("b", 6), ev("c", 12)),
+		SlidingEventWindows(10*time.Second, 5*time.Second), Lateness{})
+	assert.Equal(t, []EventWindow[event]{
+		window(-5, 5, ev("a", 1)),
+		window(0, 10, ev("a", 1), ev("b", 6)),
+		window(5, 15, ev("b", 6), ev("c", 12)),
+		window(10, 20, ev("c", 12)),
+	}, windows)
+}
+
+func TestEventTimeWindows_SlidingSideOutputLate(t *testing.T) {
+	// b is late for the window that ends at 10, but not for the window that ends at 15, so
+	// it is not emitted through the late stream
+	windows, late := EventTimeWindows(
+		Of(ev("a", 12), ev("b", 7), ev("c", 1)).WithEventTime(eventTimeOfEvent, AscendingTimestamps()),
+		SlidingEventWindows(10*time.Second, 5*time.Second), Lateness{Policy: SideOutputLate})
+	assert.Equal(t, []EventWindow[event]{
+		window(5, 15, ev("a", 12), ev("b", 7)),
+		window(10, 20, ev("a", 12)),
+	}, windows.ToSlice())
+	// c is late for all its windows
+	assert.Equal(t, []event{ev("c", 1)}, late.ToSlice())
+}
+
+func TestEventTimeWindows_Sessions(t *testing.T) {
+	windows, late := collectEventWindows(Of(
+		ev("a", 1), ev("b", 3),
+		ev("c", 10), ev("d", 20),
+		// late: the watermark is at 19, so its session would have ended at 17
+		ev("e", 12),
+		ev("f", 24), ev("g", 32),
+	), SessionEventWindows(5*time.Second), Lateness{Policy: SideOutputLate})
+	assert.Equal(t, []EventWindow[event]{
+		window(1, 8, ev("a", 1), ev("b", 3)),
+		window(10, 15, ev("c", 10)),
+		window(20, 29, ev("d", 20), ev("f", 24)),
+		window(32, 37, ev("g", 32)),
+	}, windows)
+	assert.Equal(t, []event{ev("e", 12)}, late)
+}
+
+func TestEventTimeWindows_SessionsMerge(t *testing.T) {
+	windows, _ := EventTimeWindows(
+		Of(ev("a", 1), ev("c", 10), ev("d", 2), ev("b", 6), ev("e", 30)).
+			WithEventTime(eventTimeOfEvent, BoundedOutOfOrderness(10*time.Second)),
+		SessionEventWindows(5*time.Second), Lateness{})
+	// b fills the gap between the sessions of a and c
+	assert.Equal(t, []EventWindow[event]{
+		window(1, 15, ev("a", 1), ev("c", 10), ev("d", 2), ev("b", 6)),
+		window(30, 35, ev("e", 30)),
+	}, windows.ToSlice())
+}
+
+func TestEventTimeWindows_SessionsExactGap(t *testing.T) {
+	// elements that are exactly gap apart belong to different sessions, regardless of their order
+	windows, _ := EventTimeWindows(
+		Of(ev("a", 1), ev("c", 11), ev("b", 6)).
+			WithEventTime(eventTimeOfEvent, BoundedOutOfOrderness(10*time.Second)),
+		SessionEventWindows(5*time.Second), Lateness{})
+	assert.Equal(t, []EventWindow[event]{
+		window(1, 6, ev("a", 1)),
+		window(6, 11, ev("b", 6)),
+		window(11, 16, ev("c", 11)),
+	}, windows.ToSlice())
+}
+
+func TestEventTimeWindows_SessionsRefire(t *testing.T) {
+	windows, _ := collectEventWindows(Of(
+		ev("a", 1), ev("b", 10),
+		// late, but the session of a is kept for 5 more seconds
+		ev("c", 4),
+		ev("d", 25),
+		// late, and the session of b is not kept anymore
+		ev("e", 12),
+	), SessionEventWindows(5*time.Second), Lateness{Policy: RefireLate, Allowed: 5 * time.Second})
+	assert.Equal(t, []EventWindow[event]{
+		window(1, 6, ev("a", 1)),
+		refired(window(1, 9, ev("a", 1), ev("c", 4))),
+		window(10, 15, ev("b", 10)),
+		window(25, 30, ev("d", 25)),
+	}, windows)
+}
+
+func TestEventTimeWindows_ReplayEqualsLive(t *testing.T) {
+	for _, assigner := range []WindowAssigner{
+		TumblingEventWindows(10 * time.Second),
+		SlidingEventWindows(10*time.Second, 3*time.Second),
+		SessionEventWindows(4 * time.Second),
+	} {
+		for _, lateness := range []Lateness{
+			{Policy: SideOutputLate},
+			{Policy: RefireLate, Allowed: 3 * time.Second},
+		} {
+			replayed, replayedLate := collectEventWindows(OfSlice(readings), assigner, lateness)
+
+			live := make(chan event)
+			go func() {
+				for _, r := range readings {
+					live <- r
+				}
+				close(live)
+			}()
+			windows, late := collectEventWindows(OfChannel(live), assigner, lateness)
+			require.NotEmpty(t, windows)
+			assert.Equal(t, replayed, windows)
+			assert.Equal(t, replayedLate, late)
+		}
+	}
+}
+
+func TestEventTimeWindows_Infinite(t *testing.T) {
+	windows, late := EventTimeWindows(
+		Iterate(ev("", 0), func(e event) event { return ev("", e.sec+1) }).
+			WithEventTime(eventTimeOfEvent, AscendingTimestamps()),
+		TumblingEventWindows(3*time.Second), Lateness{})
+	late.Close()
+	require.True(t, windows.isInfinite())
+	assert.Equal(t, []int{3, 3}, Map(windows.Limit(2), func(w EventWindow[event]) int {
+		return len(w.Elements)
+	}).ToSlice())
+}
+
+func TestEventTimeWindows_Panics(t *testing.T) {
+	assert.Panics(t, func() {
+		EventTimeWindows(Of(ev("a", 1)), TumblingEventWindows(time.Second), Lateness{})
+	})
+	assert.Panics(t, func() {
+		TumblingEventWindows(0)
+	})
+	assert.Panics(t, func() {
+		SlidingEventWindows(time.Second, -time.Second)
+	})
+	assert.Panics(t, func() {
+		SessionEventWindows(0)
+	})
+}
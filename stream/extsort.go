@@ -0,0 +1,191 @@
+package stream
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/mariomac/gostream/order"
+)
+
+// ExternalSorted returns a stream consisting of the elements of the input stream, sorted according
+// to the provided order.Comparator, without keeping more than memLimit elements in memory.
+// The input stream is split into runs of memLimit elements, which are sorted in memory and
+// spilled into temporary files through the provided Codec (GobCodec if nil). The sorted runs
+// are lazily merged as the returned stream is pulled.
+// Elements that are equal according to the comparator keep their relative order.
+// While the runs are merged, each of them keeps a file descriptor open, so memLimit must be
+// large enough for the number of runs (the input size divided by memLimit) to stay within
+// the limit of open files of the process.
+// The temporary files are removed when the iteration of the returned stream finishes.
+// If writing or reading a temporary file fails, the stream stops, and the error is returned
+// by the error-returning terminal operations (e.g. ToSliceErr or ForEachErr).
+// This function panics if the input stream is infinite or memLimit is not positive.
+func ExternalSorted[T any](input Stream[T], comparator order.Comparator[T], codec Codec[T], memLimit int) Stream[T] {
+	assertFinite(input)
+	if memLimit <= 0 {
+		panic(fmt.Sprintf("memLimit must be positive. Got: %d", memLimit))
+	}
+	if codec == nil {
+		codec = GobCodec[T]()
+	}
+	return &iterableStream[T]{
+		infinite: false,
+		props:    input.properties(),
+		supply: func(ex *execution) iterator[T] {
+			var next iterator[T]
+			return func() (T, bool) {
+				if next == nil {
+					next = sortRuns(ex, input, comparator, codec, memLimit)
+				}
+				return next()
+			}
+		},
+	}
+}
+
+// sortRuns sorts the input stream in runs of memLimit elements, spilling all of them but
+// the last one to temporary files, and returns an iterator that merges the sorted runs.
+func sortRuns[T any](
+	ex *execution, input Stream[T], comparator order.Comparator[T], codec Codec[T], memLimit int,
+) iterator[T] {
+	var runs []iterator[T]
+	buffer := make([]T, 0, memLimit)
+	// the order of the input is needed to keep the relative order of equal elements
+	next := input.iterator(ex.ordered())
+	for n, ok := next(); ok; n, ok = next() {
+		buffer = append(buffer, n)
+		if len(buffer) == memLimit {
+			slices.SortStableFunc(buffer, comparator)
+			run, err := spill(ex, buffer, codec)
+			if err != nil {
+				ex.fail(fmt.Errorf("spilling sorted run: %w", err))
+				return finishedIterator[T]
+			}
+			runs = append(runs, run)
+			buffer = buffer[:0]
+		}
+	}
+	// the last run does not need to be spilled
+	slices.SortStableFunc(buffer, comparator)
+	runs = append(runs, sliceIterator(buffer))
+	if len(runs) == 1 {
+		return runs[0]
+	}
+	return mergeIterators(runs, comparator)
+}
+
+// spill writes the items into a temporary file, and returns an iterator that reads them back.
+// The file is closed after being written, and only reopened when the iterator is first
+// pulled, so the runs do not hold a file descriptor while the input is being spilled.
+// The file is removed when the iterator finishes or the execution is released.
+func spill[T any](ex *execution, items []T, codec Codec[T]) (iterator[T], error) {
+	file, err := os.CreateTemp("", "gostream-sort-*")
+	if err != nil {
+		return nil, err
+	}
+	name := file.Name()
+	// reader is the file that is open while the run is being read
+	var reader *os.File
+	remove := sync.OnceFunc(func() {
+		if reader != nil {
+			_ = reader.Close()
+		}
+		_ = os.Remove(name)
+	})
+	ex.onRelease(remove)
+	writer := bufio.NewWriter(file)
+	enc := codec.NewEncoder(writer)
+	for _, n := range items {
+		if err := enc.Encode(n); err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+	var dec Decoder[T]
+	finished := false
+	return func() (T, bool) {
+		var n T
+		if finished {
+			return n, false
+		}
+		if dec == nil {
+			if reader, err = os.Open(name); err != nil {
+				finished = true
+				remove()
+				ex.fail(fmt.Errorf("reading sorted run: %w", err))
+				return finishedIterator[T]()
+			}
+			dec = codec.NewDecoder(bufio.NewReader(reader))
+		}
+		if err := dec.Decode(&n); err != nil {
+			finished = true
+			remove()
+			if !errors.Is(err, io.EOF) {
+				ex.fail(fmt.Errorf("reading sorted run: %w", err))
+			}
+			return finishedIterator[T]()
+		}
+		return n, true
+	}, nil
+}
+
+// mergeIterators lazily merges the elements of the sorted input iterators into a single sorted
+// iterator, keeping at most an element of each input in memory. Equal elements are returned
+// in the order of the iterators they come from.
+// An input is not pulled again until the element that it provided is returned and the
+// next element is requested, so the merge does not block on inputs whose next element
+// is not needed yet (e.g. channels).
+func mergeIterators[T any](inputs []iterator[T], comparator order.Comparator[T]) iterator[T] {
+	heads := newPriorityQueue(stably(comparator))
+	started := false
+	// the root of the heads has been already returned, so it must be replaced by the
+	// next element of its input
+	returned := false
+	return func() (T, bool) {
+		if !started {
+			started = true
+			for i, next := range inputs {
+				if n, ok := next(); ok {
+					heads.push(ranked[T]{val: n, seq: i})
+				}
+			}
+		} else if returned {
+			returned = false
+			input := heads.peek().seq
+			if n, ok := inputs[input](); ok {
+				heads.replaceRoot(ranked[T]{val: n, seq: input})
+			} else {
+				heads.pop()
+			}
+		}
+		if heads.len() == 0 {
+			return finishedIterator[T]()
+		}
+		returned = true
+		return heads.peek().val, true
+	}
+}
+
+// sliceIterator returns an iterator over the elements of a slice
+func sliceIterator[T any](items []T) iterator[T] {
+	return func() (T, bool) {
+		if len(items) == 0 {
+			return finishedIterator[T]()
+		}
+		n := items[0]
+		items = items[1:]
+		return n, true
+	}
+}
@@ -0,0 +1,139 @@
+package stream
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mariomac/gostream/item"
+	"github.com/mariomac/gostream/order"
+)
+
+// tempFiles returns the number of files in the temporary directory
+func tempFiles(t *testing.T) int {
+	entries, err := os.ReadDir(os.TempDir())
+	require.NoError(t, err)
+	return len(entries)
+}
+
+func TestExternalSorted(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	rnd := rand.New(rand.NewPCG(3, 4))
+	in := OfSlice(Generate(func() int { return rnd.IntN(1000) }).Limit(1000).ToSlice())
+	expected := in.Sorted(cmp.Compare[int]).ToSlice()
+	for _, memLimit := range []int{1, 7, 100, 999, 1000, 5000} {
+		assert.Equal(t, expected, ExternalSorted(in, cmp.Compare[int], nil, memLimit).ToSlice())
+	}
+	assert.Empty(t, ExternalSorted(Empty[int](), cmp.Compare[int], nil, 3).ToSlice())
+	assert.Zero(t, tempFiles(t))
+
+	assert.Panics(t, func() {
+		ExternalSorted(in, cmp.Compare[int], nil, 0)
+	})
+	assert.Panics(t, func() {
+		ExternalSorted(Iterate(1, item.Increment[int]), cmp.Compare[int], nil, 10)
+	})
+}
+
+func TestExternalSorted_Stable(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	// each element is paired with its position in the input
+	pos := 0
+	in := Map(Of(3, 1, 2, 3, 1, 2, 3, 1), func(n int) item.Pair[int, int] {
+		pos++
+		return item.Pair[int, int]{Key: n, Val: pos}
+	})
+	assert.Equal(t, []item.Pair[int, int]{
+		{Key: 1, Val: 2}, {Key: 1, Val: 5}, {Key: 1, Val: 8},
+		{Key: 2, Val: 3}, {Key: 2, Val: 6},
+		{Key: 3, Val: 1}, {Key: 3, Val: 4}, {Key: 3, Val: 7},
+	}, ExternalSorted(in, order.ByKey[int, int](cmp.Compare[int]), nil, 3).ToSlice())
+}
+
+func TestExternalSorted_StableWithFindAny(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	// the key of each element is its position modulo 10, so the first element with key 0 is 10
+	in := Iterate(1, item.Increment[int]).Limit(1000).Parallel(4).
+		Map(func(n int) int {
+			if n == 10 {
+				// delays the first element with key 0, so the workers finish out of order
+				time.Sleep(20 * time.Millisecond)
+			}
+			return n
+		})
+	byKey := func(a, b int) int { return cmp.Compare(a%10, b%10) }
+	first, ok := ExternalSorted(in, byKey, nil, 100).FindAny()
+	require.True(t, ok)
+	assert.Equal(t, 10, first)
+}
+
+func TestExternalSorted_RemovesFilesOnShortCircuit(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	sorted := ExternalSorted(Of(9, 8, 7, 6, 5, 4, 3, 2, 1), cmp.Compare[int], nil, 2)
+	for n := range sorted.Seq() {
+		assert.Equal(t, 1, n)
+		// the runs are spilled while the stream is being iterated
+		assert.Equal(t, 4, tempFiles(t))
+		break
+	}
+	assert.Zero(t, tempFiles(t))
+
+	assert.Equal(t, []int{1, 2}, sorted.Limit(2).ToSlice())
+	assert.Zero(t, tempFiles(t))
+}
+
+type jsonCodec[T any] struct{}
+
+func (jsonCodec[T]) NewEncoder(w io.Writer) Encoder[T] {
+	return jsonEncoder[T]{json.NewEncoder(w)}
+}
+
+func (jsonCodec[T]) NewDecoder(r io.Reader) Decoder[T] {
+	return jsonDecoder[T]{json.NewDecoder(r)}
+}
+
+type jsonEncoder[T any] struct{ *json.Encoder }
+
+func (j jsonEncoder[T]) Encode(n T) error { return j.Encoder.Encode(n) }
+
+type jsonDecoder[T any] struct{ *json.Decoder }
+
+func (j jsonDecoder[T]) Decode(n *T) error { return j.Decoder.Decode(n) }
+
+func TestExternalSorted_Codec(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	type person struct {
+		Name string
+		Age  int
+	}
+	people := Of(person{"Ana", 40}, person{"Bob", 25}, person{"Cai", 33}, person{"Dan", 18})
+	sorted := ExternalSorted(people, func(a, b person) int {
+		return cmp.Compare(a.Age, b.Age)
+	}, jsonCodec[person]{}, 1)
+	assert.Equal(t, []person{{"Dan", 18}, {"Bob", 25}, {"Cai", 33}, {"Ana", 40}}, sorted.ToSlice())
+	assert.Zero(t, tempFiles(t))
+}
+
+type failingCodec struct{ jsonCodec[int] }
+
+func (failingCodec) NewEncoder(io.Writer) Encoder[int] { return failingEncoder{} }
+
+type failingEncoder struct{}
+
+func (failingEncoder) Encode(int) error { return errors.New("disk full") }
+
+func TestExternalSorted_Error(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	sorted, err := ExternalSorted(Of(3, 2, 1), cmp.Compare[int], failingCodec{}, 2).ToSliceErr()
+	assert.Empty(t, sorted)
+	assert.ErrorContains(t, err, "disk full")
+	assert.Zero(t, tempFiles(t))
+}
@@ -0,0 +1,207 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"maps"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/mariomac/gostream/item"
+)
+
+// FileStore is a StateStore that keeps its entries in memory, and persists them into a local
+// file, so a stream that is restarted can continue from the state that a previous run left.
+// Each change is appended to the file as a pair of the Key and its value, which is absent if
+// the key was deleted. When the store is opened, the changes are replayed and the file is
+// compacted, so it only contains the latest value of each key.
+type FileStore[K comparable, V any] struct {
+	mu      sync.RWMutex
+	path    string
+	codec   Codec[item.Pair[K, item.Optional[V]]]
+	entries map[K]V
+	file    *os.File
+	enc     Encoder[item.Pair[K, item.Optional[V]]]
+}
+
+// OpenFileStore opens the FileStore that persists its entries in the provided path, creating
+// the file if it does not exist. The entries are encoded with the provided Codec, which must be
+// the same that was used by any previous store in the same path (e.g. GobCodec).
+// A truncated last change, as left by a crash in the middle of a write, is ignored.
+// The returned store must be closed after its usage.
+func OpenFileStore[K comparable, V any](path string, codec Codec[item.Pair[K, item.Optional[V]]]) (*FileStore[K, V], error) {
+	fs := &FileStore[K, V]{path: path, codec: codec, entries: map[K]V{}}
+	if err := fs.load(); err != nil {
+		return nil, err
+	}
+	if err := fs.compact(); err != nil {
+		return nil, err
+	}
+	return fs, nil
+}
+
+// load replays the changes of the store file into the entries map
+func (fs *FileStore[K, V]) load() error {
+	f, err := os.Open(fs.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("opening state file: %w", err)
+	}
+	defer f.Close()
+	dec := fs.codec.NewDecoder(f)
+	for {
+		var change item.Pair[K, item.Optional[V]]
+		if err := dec.Decode(&change); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil
+			}
+			return fmt.Errorf("reading state file %s: %w", fs.path, err)
+		}
+		if change.Val.Present {
+			fs.entries[change.Key] = change.Val.Val
+		} else {
+			delete(fs.entries, change.Key)
+		}
+	}
+}
+
+// compact rewrites the store file with the current entries, replacing it atomically, and
+// reopens it to append the next changes
+func (fs *FileStore[K, V]) compact() error {
+	tmp, err := os.CreateTemp(filepath.Dir(fs.path), ".gostream-state-*")
+	if err != nil {
+		return fmt.Errorf("compacting state file: %w", err)
+	}
+	enc := fs.codec.NewEncoder(tmp)
+	for k, v := range fs.entries {
+		if err := enc.Encode(item.Pair[K, item.Optional[V]]{Key: k, Val: item.Some(v)}); err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return fmt.Errorf("compacting state file: %w", err)
+		}
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("compacting state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), fs.path); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("compacting state file: %w", err)
+	}
+	if fs.file != nil {
+		fs.file.Close()
+	}
+	// the encoder keeps writing at the end of the renamed file, so the file contains a single
+	// encoded stream that a decoder can read from the beginning
+	fs.file, fs.enc = tmp, enc
+	return nil
+}
+
+func (fs *FileStore[K, V]) Get(key K) (V, bool) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	v, ok := fs.entries[key]
+	return v, ok
+}
+
+func (fs *FileStore[K, V]) Put(key K, val V) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if err := fs.append(key, item.Some(val)); err != nil {
+		return err
+	}
+	fs.entries[key] = val
+	return nil
+}
+
+func (fs *FileStore[K, V]) Delete(key K) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if _, ok := fs.entries[key]; !ok {
+		return nil
+	}
+	if err := fs.append(key, item.None[V]()); err != nil {
+		return err
+	}
+	delete(fs.entries, key)
+	return nil
+}
+
+func (fs *FileStore[K, V]) append(key K, val item.Optional[V]) error {
+	if fs.file == nil {
+		return fmt.Errorf("writing state file %s: %w", fs.path, os.ErrClosed)
+	}
+	if err := fs.enc.Encode(item.Pair[K, item.Optional[V]]{Key: key, Val: val}); err != nil {
+		return fmt.Errorf("writing state file %s: %w", fs.path, err)
+	}
+	return nil
+}
+
+func (fs *FileStore[K, V]) Clear() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.file == nil {
+		return fmt.Errorf("writing state file %s: %w", fs.path, os.ErrClosed)
+	}
+	clear(fs.entries)
+	return fs.compact()
+}
+
+func (fs *FileStore[K, V]) Len() int {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	return len(fs.entries)
+}
+
+// All iterates the entries in no particular order.
+func (fs *FileStore[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		fs.mu.RLock()
+		snapshot := maps.Clone(fs.entries)
+		fs.mu.RUnlock()
+		for k, v := range snapshot {
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// Compact rewrites the store file so it only contains the latest value of each key, which
+// reduces its size and the time to open it after many updates of the same keys.
+func (fs *FileStore[K, V]) Compact() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.file == nil {
+		return fmt.Errorf("compacting state file %s: %w", fs.path, os.ErrClosed)
+	}
+	return fs.compact()
+}
+
+// Sync commits the appended changes to stable storage.
+func (fs *FileStore[K, V]) Sync() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.file == nil {
+		return fmt.Errorf("syncing state file %s: %w", fs.path, os.ErrClosed)
+	}
+	return fs.file.Sync()
+}
+
+// Close the store file. The entries of the store can still be read, but they can't be changed.
+func (fs *FileStore[K, V]) Close() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.file == nil {
+		return nil
+	}
+	err := fs.file.Close()
+	fs.file, fs.enc = nil, nil
+	return err
+}
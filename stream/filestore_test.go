@@ -0,0 +1,108 @@
+package stream
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mariomac/gostream/item"
+)
+
+func openCounters(t *testing.T, path string) *FileStore[string, int] {
+	t.Helper()
+	fs, err := OpenFileStore[string, int](path, GobCodec[item.Pair[string, item.Optional[int]]]())
+	require.NoError(t, err)
+	return fs
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counters")
+	fs := openCounters(t, path)
+	assert.Zero(t, fs.Len())
+	require.NoError(t, fs.Put("a", 1))
+	require.NoError(t, fs.Put("b", 2))
+	require.NoError(t, fs.Put("a", 3))
+	require.NoError(t, fs.Delete("b"))
+	require.NoError(t, fs.Put("c", 0))
+	require.NoError(t, fs.Close())
+	// a closed store can be read but not changed
+	v, ok := fs.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+	assert.ErrorIs(t, fs.Put("a", 4), os.ErrClosed)
+
+	// reopening the store replays its changes
+	fs = openCounters(t, path)
+	assert.Equal(t, map[string]int{"a": 3, "c": 0}, maps.Collect(fs.All()))
+	require.NoError(t, fs.Clear())
+	require.NoError(t, fs.Put("d", 4))
+	require.NoError(t, fs.Close())
+
+	fs = openCounters(t, path)
+	assert.Equal(t, map[string]int{"d": 4}, maps.Collect(fs.All()))
+	require.NoError(t, fs.Close())
+}
+
+func TestFileStore_Compact(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "counters")
+	fs := openCounters(t, path)
+	for i := range 1000 {
+		require.NoError(t, fs.Put("a", i))
+	}
+	require.NoError(t, fs.Sync())
+	before, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, fs.Compact())
+	after, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Less(t, after.Size(), before.Size())
+
+	// the changes after compacting are appended to the compacted file
+	require.NoError(t, fs.Put("b", 1))
+	require.NoError(t, fs.Close())
+	fs = openCounters(t, path)
+	assert.Equal(t, map[string]int{"a": 999, "b": 1}, maps.Collect(fs.All()))
+	require.NoError(t, fs.Close())
+
+	// no temporary files are left behind
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestFileStore_TruncatedChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counters")
+	fs := openCounters(t, path)
+	require.NoError(t, fs.Put("a", 1))
+	require.NoError(t, fs.Put("b", 2))
+	require.NoError(t, fs.Close())
+
+	// simulate a crash in the middle of writing the last change
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(path, info.Size()-1))
+
+	fs = openCounters(t, path)
+	assert.Equal(t, map[string]int{"a": 1}, maps.Collect(fs.All()))
+	require.NoError(t, fs.Close())
+}
+
+func TestFileStore_Stream(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "totals")
+	// a first run of the pipeline stops before the input ends
+	fs := openCounters(t, path)
+	assert.Equal(t, []kv[int]{{Key: "apple", Val: 3}, {Key: "pear", Val: 1}},
+		ScanByKey(sales, fs, 0, item.Add[int]).Limit(2).ToSlice())
+	require.NoError(t, fs.Close())
+
+	// the next run continues the aggregation with the rest of the input
+	fs = openCounters(t, path)
+	assert.Equal(t, []kv[int]{{Key: "apple", Val: 8}, {Key: "plum", Val: 2}, {Key: "pear", Val: 5}},
+		ScanByKey(sales.Skip(2), fs, 0, item.Add[int]).ToSlice())
+	require.NoError(t, fs.Close())
+}
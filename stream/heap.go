@@ -0,0 +1,78 @@
+package stream
+
+import (
+	"container/heap"
+
+	"github.com/mariomac/gostream/order"
+)
+
+// priorityQueue is a binary heap whose root is the lowest element according to its comparator
+type priorityQueue[T any] struct {
+	items      []T
+	comparator order.Comparator[T]
+}
+
+func newPriorityQueue[T any](comparator order.Comparator[T]) *priorityQueue[T] {
+	return &priorityQueue[T]{comparator: comparator}
+}
+
+// heapify returns a priorityQueue containing the provided items, which are arranged
+// in O(n) time. The queue takes the ownership of the items slice.
+func heapify[T any](items []T, comparator order.Comparator[T]) *priorityQueue[T] {
+	pq := &priorityQueue[T]{items: items, comparator: comparator}
+	heap.Init((*heapAdapter[T])(pq))
+	return pq
+}
+
+func (pq *priorityQueue[T]) push(n T) {
+	heap.Push((*heapAdapter[T])(pq), n)
+}
+
+func (pq *priorityQueue[T]) pop() T {
+	return heap.Pop((*heapAdapter[T])(pq)).(T)
+}
+
+// peek returns the lowest element without removing it from the queue
+func (pq *priorityQueue[T]) peek() T {
+	return pq.items[0]
+}
+
+// replaceRoot replaces the lowest element by the provided element, which is cheaper
+// than a pop followed by a push
+func (pq *priorityQueue[T]) replaceRoot(n T) {
+	pq.items[0] = n
+	heap.Fix((*heapAdapter[T])(pq), 0)
+}
+
+func (pq *priorityQueue[T]) len() int {
+	return len(pq.items)
+}
+
+// heapAdapter implements heap.Interface, keeping its methods out of the priorityQueue API
+type heapAdapter[T any] priorityQueue[T]
+
+func (h *heapAdapter[T]) Len() int {
+	return len(h.items)
+}
+
+func (h *heapAdapter[T]) Less(i, j int) bool {
+	return h.comparator(h.items[i], h.items[j]) < 0
+}
+
+func (h *heapAdapter[T]) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+}
+
+func (h *heapAdapter[T]) Push(x any) {
+	h.items = append(h.items, x.(T))
+}
+
+func (h *heapAdapter[T]) Pop() any {
+	last := len(h.items) - 1
+	n := h.items[last]
+	var zero T
+	// avoid retaining references to the popped elements
+	h.items[last] = zero
+	h.items = h.items[:last]
+	return n
+}
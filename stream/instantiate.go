@@ -14,10 +14,10 @@ func Of[T any](elems ...T) Stream[T] {
 
 // OfSlice creates a Stream from a slice.
 func OfSlice[T any](elems []T) Stream[T] {
-	return &iterableStream[T]{supply: func() iterator[T] {
+	return &iterableStream[T]{supply: func(ex *execution) iterator[T] {
 		items := elems
 		return func() (T, bool) {
-			if len(items) == 0 {
+			if len(items) == 0 || ex.cancelled() {
 				return finishedIterator[T]()
 			}
 			n := items[0]
@@ -33,8 +33,11 @@ func OfSlice[T any](elems []T) Stream[T] {
 func Generate[T any](supplier func() T) Stream[T] {
 	return &iterableStream[T]{
 		infinite: true,
-		supply: func() iterator[T] {
+		supply: func(ex *execution) iterator[T] {
 			return func() (T, bool) {
+				if ex.cancelled() {
+					return finishedIterator[T]()
+				}
 				return supplier(), true
 			}
 		},
@@ -50,9 +53,12 @@ func Generate[T any](supplier func() T) Stream[T] {
 func Iterate[T any](seed T, f func(T) T) Stream[T] {
 	return &iterableStream[T]{
 		infinite: true,
-		supply: func() iterator[T] {
+		supply: func(ex *execution) iterator[T] {
 			lastElement := seed
 			return func() (T, bool) {
+				if ex.cancelled() {
+					return finishedIterator[T]()
+				}
 				i := lastElement
 				lastElement = f(lastElement)
 				return i, true
@@ -66,9 +72,10 @@ func Iterate[T any](seed T, f func(T) T) Stream[T] {
 func Concat[T any](a, b Stream[T]) Stream[T] {
 	return &iterableStream[T]{
 		infinite: a.isInfinite() || b.isInfinite(),
-		supply: func() iterator[T] {
+		props:    a.properties().closingWith(b.properties()),
+		supply: func(ex *execution) iterator[T] {
 			first := true
-			next := a.iterator()
+			next := a.iterator(ex)
 			return func() (T, bool) {
 				n, ok := next()
 				if ok {
@@ -76,7 +83,7 @@ func Concat[T any](a, b Stream[T]) Stream[T] {
 				}
 				if first {
 					first = false
-					next = b.iterator()
+					next = b.iterator(ex)
 				} else {
 					next = finishedIterator[T]
 				}
@@ -89,7 +96,7 @@ func Concat[T any](a, b Stream[T]) Stream[T] {
 // Empty returns an empty stream
 func Empty[T any]() Stream[T] {
 	return &iterableStream[T]{
-		supply: func() iterator[T] {
+		supply: func(*execution) iterator[T] {
 			return finishedIterator[T]
 		},
 	}
@@ -99,7 +106,7 @@ func Empty[T any]() Stream[T] {
 // a key/value entry of the source map.
 func OfMap[K comparable, V any](source map[K]V) Stream[item.Pair[K, V]] {
 	return &iterableStream[item.Pair[K, V]]{
-		supply: func() iterator[item.Pair[K, V]] {
+		supply: func(ex *execution) iterator[item.Pair[K, V]] {
 			// the map slice is instantiated lazily
 			// TODO: use low-level code to directly iterate Map?
 			items := make([]item.Pair[K, V], 0, len(source))
@@ -107,7 +114,7 @@ func OfMap[K comparable, V any](source map[K]V) Stream[item.Pair[K, V]] {
 				items = append(items, item.Pair[K, V]{Key: k, Val: v})
 			}
 			return func() (item.Pair[K, V], bool) {
-				if len(items) == 0 {
+				if len(items) == 0 || ex.cancelled() {
 					return finishedIterator[item.Pair[K, V]]()
 				}
 				n := items[0]
@@ -120,13 +127,18 @@ func OfMap[K comparable, V any](source map[K]V) Stream[item.Pair[K, V]] {
 
 // OfChannel creates a Stream from an input channel. The Stream won't end until
 // the source channel is closed, so some operations (Distinct, Sorted, ToSlice, Count...) will
-// block the execution until the source is closed.
+// block the execution until the source is closed, or until the context bound to the stream
+// (see the WithContext method) is done.
 func OfChannel[T any](source <-chan T) Stream[T] {
 	return &iterableStream[T]{
-		supply: func() iterator[T] {
+		supply: func(ex *execution) iterator[T] {
 			return func() (T, bool) {
-				v, ok := <-source
-				return v, ok
+				select {
+				case v, ok := <-source:
+					return v, ok
+				case <-ex.ctx.Done():
+					return finishedIterator[T]()
+				}
 			}
 		},
 	}
@@ -135,9 +147,15 @@ func OfChannel[T any](source <-chan T) Stream[T] {
 // OfSeq creates a Stream[T] from a standard iter.Seq[T] iterator
 func OfSeq[T any](source iter.Seq[T]) Stream[T] {
 	return &iterableStream[T]{
-		supply: func() iterator[T] {
-			pull, _ := iter.Pull(source)
-			return pull
+		supply: func(ex *execution) iterator[T] {
+			pull, stop := iter.Pull(source)
+			ex.onRelease(stop)
+			return func() (T, bool) {
+				if ex.cancelled() {
+					return finishedIterator[T]()
+				}
+				return pull()
+			}
 		},
 	}
 }
@@ -145,9 +163,13 @@ func OfSeq[T any](source iter.Seq[T]) Stream[T] {
 // OfSeq2 creates a Stream[item.Pair[K, V]] from a standard iter.Seq2[K, V] iterator.
 func OfSeq2[K comparable, V any](source iter.Seq2[K, V]) Stream[item.Pair[K, V]] {
 	return &iterableStream[item.Pair[K, V]]{
-		supply: func() iterator[item.Pair[K, V]] {
-			pull, _ := iter.Pull2(source)
+		supply: func(ex *execution) iterator[item.Pair[K, V]] {
+			pull, stop := iter.Pull2(source)
+			ex.onRelease(stop)
 			return func() (item.Pair[K, V], bool) {
+				if ex.cancelled() {
+					return finishedIterator[item.Pair[K, V]]()
+				}
 				k, v, ok := pull()
 				return item.Pair[K, V]{Key: k, Val: v}, ok
 			}
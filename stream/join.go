@@ -0,0 +1,298 @@
+package stream
+
+import (
+	"github.com/mariomac/gostream/item"
+	"github.com/mariomac/gostream/order"
+)
+
+// Join returns a stream with the Key and both values of each pair of elements from the left and
+// right streams that have the same Key. Elements without a match in the other stream are discarded.
+// It is implemented as a hash join: the right stream is entirely loaded into memory when the
+// returned stream is iterated, while the left stream is processed lazily, so it can be an infinite
+// stream or a stream created from a channel. The joined elements are emitted in the order of the
+// left stream and, for equal keys, in the order of the right stream.
+// For large streams that are already sorted by Key, JoinSorted does not require keeping the
+// right stream in memory.
+// This function panics if the right stream is infinite.
+func Join[K comparable, L, R any](
+	left Stream[item.Pair[K, L]], right Stream[item.Pair[K, R]],
+) Stream[item.Pair[K, item.Joined[L, R]]] {
+	return Map(hashJoin(left, right, false, false), innerJoined[K, L, R])
+}
+
+// LeftJoin returns a stream with the Key and both values of each pair of elements from the left
+// and right streams that have the same Key, as Join does. Additionally, the elements of the left
+// stream without any match in the right stream are emitted with an absent right value.
+// It is implemented as a hash join: the right stream is entirely loaded into memory when the
+// returned stream is iterated, while the left stream is processed lazily.
+// This function panics if the right stream is infinite.
+func LeftJoin[K comparable, L, R any](
+	left Stream[item.Pair[K, L]], right Stream[item.Pair[K, R]],
+) Stream[item.Pair[K, item.Joined[L, item.Optional[R]]]] {
+	return Map(hashJoin(left, right, true, false), leftJoined[K, L, R])
+}
+
+// RightJoin returns a stream with the Key and both values of each pair of elements from the left
+// and right streams that have the same Key, as Join does. Additionally, the elements of the right
+// stream without any match in the left stream are emitted with an absent left value.
+// It is implemented as a hash join: the left stream is entirely loaded into memory when the
+// returned stream is iterated, while the right stream is processed lazily. The joined elements
+// are emitted in the order of the right stream.
+// This function panics if the left stream is infinite.
+func RightJoin[K comparable, L, R any](
+	left Stream[item.Pair[K, L]], right Stream[item.Pair[K, R]],
+) Stream[item.Pair[K, item.Joined[item.Optional[L], R]]] {
+	return Map(hashJoin(right, left, true, false), rightJoined[K, L, R])
+}
+
+// FullOuterJoin returns a stream with the Key and both values of each pair of elements from the
+// left and right streams that have the same Key, as Join does. Additionally, the elements of any
+// stream without a match in the other stream are emitted with an absent value for the other side.
+// It is implemented as a hash join: the right stream is entirely loaded into memory when the
+// returned stream is iterated, while the left stream is processed lazily. The elements of the
+// right stream without a match are emitted after the left stream ends.
+// This function panics if the right stream is infinite.
+func FullOuterJoin[K comparable, L, R any](
+	left Stream[item.Pair[K, L]], right Stream[item.Pair[K, R]],
+) Stream[item.Pair[K, item.Joined[item.Optional[L], item.Optional[R]]]] {
+	return hashJoin(left, right, true, true)
+}
+
+// JoinSorted is equivalent to Join, but implemented as a merge join: both streams must be
+// already sorted by Key, according to the provided comparator of keys (e.g. order.Natural[K] or
+// cmp.Compare[K]), and are processed lazily in a single pass, only keeping in memory the elements
+// with the same key.
+// The joined elements are emitted sorted by Key.
+// Since all the elements with the same Key must be grouped before being joined, an element of
+// a stream created from a channel is not joined until the next element with a different Key
+// arrives, or the channel is closed.
+func JoinSorted[K comparable, L, R any](
+	comparator order.Comparator[K], left Stream[item.Pair[K, L]], right Stream[item.Pair[K, R]],
+) Stream[item.Pair[K, item.Joined[L, R]]] {
+	return Map(mergeJoin(comparator, left, right, false, false), innerJoined[K, L, R])
+}
+
+// LeftJoinSorted is equivalent to LeftJoin, but implemented as a merge join over streams that
+// are already sorted by Key, as JoinSorted does.
+func LeftJoinSorted[K comparable, L, R any](
+	comparator order.Comparator[K], left Stream[item.Pair[K, L]], right Stream[item.Pair[K, R]],
+) Stream[item.Pair[K, item.Joined[L, item.Optional[R]]]] {
+	return Map(mergeJoin(comparator, left, right, true, false), leftJoined[K, L, R])
+}
+
+// RightJoinSorted is equivalent to RightJoin, but implemented as a merge join over streams that
+// are already sorted by Key, as JoinSorted does.
+func RightJoinSorted[K comparable, L, R any](
+	comparator order.Comparator[K], left Stream[item.Pair[K, L]], right Stream[item.Pair[K, R]],
+) Stream[item.Pair[K, item.Joined[item.Optional[L], R]]] {
+	return Map(mergeJoin(comparator, right, left, true, false), rightJoined[K, L, R])
+}
+
+// FullOuterJoinSorted is equivalent to FullOuterJoin, but implemented as a merge join over
+// streams that are already sorted by Key, as JoinSorted does.
+func FullOuterJoinSorted[K comparable, L, R any](
+	comparator order.Comparator[K], left Stream[item.Pair[K, L]], right Stream[item.Pair[K, R]],
+) Stream[item.Pair[K, item.Joined[item.Optional[L], item.Optional[R]]]] {
+	return mergeJoin(comparator, left, right, true, true)
+}
+
+// outerJoined is an element of an outer join, where any of the sides might be absent
+type outerJoined[K comparable, L, R any] = item.Pair[K, item.Joined[item.Optional[L], item.Optional[R]]]
+
+func joinOf[K comparable, L, R any](key K, left item.Optional[L], right item.Optional[R]) outerJoined[K, L, R] {
+	return outerJoined[K, L, R]{Key: key, Val: item.Joined[item.Optional[L], item.Optional[R]]{Left: left, Right: right}}
+}
+
+func innerJoined[K comparable, L, R any](p outerJoined[K, L, R]) item.Pair[K, item.Joined[L, R]] {
+	return item.Pair[K, item.Joined[L, R]]{
+		Key: p.Key, Val: item.Joined[L, R]{Left: p.Val.Left.Val, Right: p.Val.Right.Val},
+	}
+}
+
+func leftJoined[K comparable, L, R any](p outerJoined[K, L, R]) item.Pair[K, item.Joined[L, item.Optional[R]]] {
+	return item.Pair[K, item.Joined[L, item.Optional[R]]]{
+		Key: p.Key, Val: item.Joined[L, item.Optional[R]]{Left: p.Val.Left.Val, Right: p.Val.Right},
+	}
+}
+
+// rightJoined swaps the sides of a left join whose left side is the right stream
+func rightJoined[K comparable, L, R any](p outerJoined[K, R, L]) item.Pair[K, item.Joined[item.Optional[L], R]] {
+	return item.Pair[K, item.Joined[item.Optional[L], R]]{
+		Key: p.Key, Val: item.Joined[item.Optional[L], R]{Left: p.Val.Right, Right: p.Val.Left.Val},
+	}
+}
+
+// hashJoin loads the right stream into a map, which is probed by each element of the left stream.
+// If leftOuter is true, the left elements without a match are also emitted. If rightOuter is
+// true, the right elements without a match are emitted when the left stream ends.
+func hashJoin[K comparable, L, R any](
+	left Stream[item.Pair[K, L]], right Stream[item.Pair[K, R]], leftOuter, rightOuter bool,
+) Stream[outerJoined[K, L, R]] {
+	assertFinite(right)
+	return &iterableStream[outerJoined[K, L, R]]{
+		infinite: left.isInfinite(),
+		props:    left.properties().closingWith(right.properties()),
+		supply: func(ex *execution) iterator[outerJoined[K, L, R]] {
+			ord := ex.ordered()
+			var table map[K][]R
+			// keys of the right stream, in order of appearance
+			var keys []K
+			matched := map[K]struct{}{}
+			var pending []outerJoined[K, L, R]
+			next := left.iterator(ord)
+			return func() (outerJoined[K, L, R], bool) {
+				if table == nil {
+					table = map[K][]R{}
+					nextR := right.iterator(ord)
+					for r, ok := nextR(); ok; r, ok = nextR() {
+						if _, ok := table[r.Key]; !ok {
+							keys = append(keys, r.Key)
+						}
+						table[r.Key] = append(table[r.Key], r.Val)
+					}
+				}
+				for len(pending) == 0 {
+					if next == nil {
+						return finishedIterator[outerJoined[K, L, R]]()
+					}
+					l, ok := next()
+					if !ok {
+						next = nil
+						if rightOuter {
+							for _, k := range keys {
+								if _, ok := matched[k]; ok {
+									continue
+								}
+								for _, r := range table[k] {
+									pending = append(pending, joinOf(k, item.None[L](), item.Some(r)))
+								}
+							}
+						}
+						continue
+					}
+					if rs, ok := table[l.Key]; ok {
+						matched[l.Key] = struct{}{}
+						for _, r := range rs {
+							pending = append(pending, joinOf(l.Key, item.Some(l.Val), item.Some(r)))
+						}
+					} else if leftOuter {
+						pending = append(pending, joinOf(l.Key, item.Some(l.Val), item.None[R]()))
+					}
+				}
+				n := pending[0]
+				pending = pending[1:]
+				return n, true
+			}
+		},
+	}
+}
+
+// mergeJoin joins the groups of elements with the same key from two streams that are sorted
+// by key. If leftOuter or rightOuter are true, the groups of the left or right stream without a
+// match in the other stream are also emitted.
+func mergeJoin[K comparable, L, R any](
+	comparator order.Comparator[K], left Stream[item.Pair[K, L]], right Stream[item.Pair[K, R]],
+	leftOuter, rightOuter bool,
+) Stream[outerJoined[K, L, R]] {
+	return &iterableStream[outerJoined[K, L, R]]{
+		infinite: left.isInfinite() && right.isInfinite() ||
+			leftOuter && left.isInfinite() || rightOuter && right.isInfinite(),
+		props: left.properties().closingWith(right.properties()),
+		supply: func(ex *execution) iterator[outerJoined[K, L, R]] {
+			ord := ex.ordered()
+			nextL := groupSorted(left.iterator(ord), comparator)
+			nextR := groupSorted(right.iterator(ord), comparator)
+			var gl keyGroup[K, L]
+			var gr keyGroup[K, R]
+			var okL, okR bool
+			pullL, pullR := true, true
+			var pending []outerJoined[K, L, R]
+			leftOnly := func() {
+				for _, l := range gl.vals {
+					pending = append(pending, joinOf(gl.key, item.Some(l), item.None[R]()))
+				}
+			}
+			rightOnly := func() {
+				for _, r := range gr.vals {
+					pending = append(pending, joinOf(gr.key, item.None[L](), item.Some(r)))
+				}
+			}
+			return func() (outerJoined[K, L, R], bool) {
+				for len(pending) == 0 {
+					if pullL {
+						gl, okL = nextL()
+						pullL = false
+					}
+					if pullR {
+						gr, okR = nextR()
+						pullR = false
+					}
+					switch {
+					case !okL && (!okR || !rightOuter), !okR && !leftOuter:
+						// no more elements can be joined
+						return finishedIterator[outerJoined[K, L, R]]()
+					case !okR:
+						leftOnly()
+						pullL = true
+						continue
+					case !okL:
+						rightOnly()
+						pullR = true
+						continue
+					}
+					switch c := comparator(gl.key, gr.key); {
+					case c < 0:
+						if leftOuter {
+							leftOnly()
+						}
+						pullL = true
+					case c > 0:
+						if rightOuter {
+							rightOnly()
+						}
+						pullR = true
+					default:
+						for _, l := range gl.vals {
+							for _, r := range gr.vals {
+								pending = append(pending, joinOf(gl.key, item.Some(l), item.Some(r)))
+							}
+						}
+						pullL, pullR = true, true
+					}
+				}
+				n := pending[0]
+				pending = pending[1:]
+				return n, true
+			}
+		},
+	}
+}
+
+// keyGroup contains the values of consecutive pairs with the same key
+type keyGroup[K comparable, V any] struct {
+	key  K
+	vals []V
+}
+
+// groupSorted returns an iterator that groups the values of the consecutive pairs of the
+// input iterator whose keys are equal according to the comparator
+func groupSorted[K comparable, V any](
+	next iterator[item.Pair[K, V]], comparator order.Comparator[K],
+) func() (keyGroup[K, V], bool) {
+	head, ok := item.Pair[K, V]{}, false
+	started := false
+	return func() (keyGroup[K, V], bool) {
+		if !started {
+			started = true
+			head, ok = next()
+		}
+		if !ok {
+			return keyGroup[K, V]{}, false
+		}
+		group := keyGroup[K, V]{key: head.Key, vals: []V{head.Val}}
+		for head, ok = next(); ok && comparator(group.key, head.Key) == 0; head, ok = next() {
+			group.vals = append(group.vals, head.Val)
+		}
+		return group, true
+	}
+}
@@ -0,0 +1,150 @@
+package stream
+
+import (
+	"cmp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mariomac/gostream/item"
+)
+
+type kv[V any] = item.Pair[string, V]
+
+// people by city
+var people = Of(
+	kv[string]{Key: "BCN", Val: "Ana"},
+	kv[string]{Key: "MAD", Val: "Bob"},
+	kv[string]{Key: "BCN", Val: "Cai"},
+	kv[string]{Key: "VLC", Val: "Dan"},
+)
+
+// population by city
+var population = Of(
+	kv[int]{Key: "MAD", Val: 3},
+	kv[int]{Key: "BCN", Val: 2},
+	kv[int]{Key: "LON", Val: 9},
+)
+
+func joined[L, R any](key string, l L, r R) item.Pair[string, item.Joined[L, R]] {
+	return item.Pair[string, item.Joined[L, R]]{Key: key, Val: item.Joined[L, R]{Left: l, Right: r}}
+}
+
+func TestJoin(t *testing.T) {
+	assert.Equal(t, []item.Pair[string, item.Joined[string, int]]{
+		joined("BCN", "Ana", 2),
+		joined("MAD", "Bob", 3),
+		joined("BCN", "Cai", 2),
+	}, Join(people, population).ToSlice())
+
+	// duplicate keys in both sides
+	assert.Equal(t, []item.Pair[string, item.Joined[int, string]]{
+		joined("a", 1, "x"), joined("a", 1, "y"), joined("a", 2, "x"), joined("a", 2, "y"),
+	}, Join(Of(kv[int]{Key: "a", Val: 1}, kv[int]{Key: "a", Val: 2}),
+		Of(kv[string]{Key: "a", Val: "x"}, kv[string]{Key: "a", Val: "y"})).ToSlice())
+
+	assert.Empty(t, Join(people, Empty[kv[int]]()).ToSlice())
+	assert.Panics(t, func() {
+		Join(people, Generate(func() kv[int] { return kv[int]{} }))
+	})
+}
+
+func TestLeftJoin(t *testing.T) {
+	assert.Equal(t, []item.Pair[string, item.Joined[string, item.Optional[int]]]{
+		joined("BCN", "Ana", item.Some(2)),
+		joined("MAD", "Bob", item.Some(3)),
+		joined("BCN", "Cai", item.Some(2)),
+		joined("VLC", "Dan", item.None[int]()),
+	}, LeftJoin(people, population).ToSlice())
+}
+
+func TestRightJoin(t *testing.T) {
+	assert.Equal(t, []item.Pair[string, item.Joined[item.Optional[string], int]]{
+		joined("MAD", item.Some("Bob"), 3),
+		joined("BCN", item.Some("Ana"), 2),
+		joined("BCN", item.Some("Cai"), 2),
+		joined("LON", item.None[string](), 9),
+	}, RightJoin(people, population).ToSlice())
+}
+
+func TestFullOuterJoin(t *testing.T) {
+	assert.Equal(t, []item.Pair[string, item.Joined[item.Optional[string], item.Optional[int]]]{
+		joined("BCN", item.Some("Ana"), item.Some(2)),
+		joined("MAD", item.Some("Bob"), item.Some(3)),
+		joined("BCN", item.Some("Cai"), item.Some(2)),
+		joined("VLC", item.Some("Dan"), item.None[int]()),
+		joined("LON", item.None[string](), item.Some(9)),
+	}, FullOuterJoin(people, population).ToSlice())
+}
+
+func TestJoin_Channel(t *testing.T) {
+	events := make(chan kv[string])
+	enriched := make(chan item.Pair[string, item.Joined[string, item.Optional[int]]])
+	go func() {
+		LeftJoin(OfChannel(events), population).ForEach(func(e item.Pair[string, item.Joined[string, item.Optional[int]]]) {
+			enriched <- e
+		})
+		close(enriched)
+	}()
+	events <- kv[string]{Key: "MAD", Val: "login"}
+	assert.Equal(t, joined("MAD", "login", item.Some(3)), <-enriched)
+	events <- kv[string]{Key: "PAR", Val: "logout"}
+	assert.Equal(t, joined("PAR", "logout", item.None[int]()), <-enriched)
+	close(events)
+	_, ok := <-enriched
+	assert.False(t, ok)
+}
+
+func TestJoinSorted(t *testing.T) {
+	byKey := cmp.Compare[string]
+	sortedPeople := people.Sorted(func(a, b kv[string]) int { return cmp.Compare(a.Key, b.Key) })
+	sortedPopulation := population.Sorted(func(a, b kv[int]) int { return cmp.Compare(a.Key, b.Key) })
+
+	assert.Equal(t, []item.Pair[string, item.Joined[string, int]]{
+		joined("BCN", "Ana", 2),
+		joined("BCN", "Cai", 2),
+		joined("MAD", "Bob", 3),
+	}, JoinSorted(byKey, sortedPeople, sortedPopulation).ToSlice())
+
+	assert.Equal(t, []item.Pair[string, item.Joined[string, item.Optional[int]]]{
+		joined("BCN", "Ana", item.Some(2)),
+		joined("BCN", "Cai", item.Some(2)),
+		joined("MAD", "Bob", item.Some(3)),
+		joined("VLC", "Dan", item.None[int]()),
+	}, LeftJoinSorted(byKey, sortedPeople, sortedPopulation).ToSlice())
+
+	assert.Equal(t, []item.Pair[string, item.Joined[item.Optional[string], int]]{
+		joined("BCN", item.Some("Ana"), 2),
+		joined("BCN", item.Some("Cai"), 2),
+		joined("LON", item.None[string](), 9),
+		joined("MAD", item.Some("Bob"), 3),
+	}, RightJoinSorted(byKey, sortedPeople, sortedPopulation).ToSlice())
+
+	assert.Equal(t, []item.Pair[string, item.Joined[item.Optional[string], item.Optional[int]]]{
+		joined("BCN", item.Some("Ana"), item.Some(2)),
+		joined("BCN", item.Some("Cai"), item.Some(2)),
+		joined("LON", item.None[string](), item.Some(9)),
+		joined("MAD", item.Some("Bob"), item.Some(3)),
+		joined("VLC", item.Some("Dan"), item.None[int]()),
+	}, FullOuterJoinSorted(byKey, sortedPeople, sortedPopulation).ToSlice())
+}
+
+func TestJoinSorted_Infinite(t *testing.T) {
+	squares := Map(Iterate(1, item.Increment[int]), func(n int) item.Pair[int, int] {
+		return item.Pair[int, int]{Key: n, Val: n * n}
+	})
+	evens := Map(Iterate(2, func(n int) int { return n + 2 }), func(n int) item.Pair[int, bool] {
+		return item.Pair[int, bool]{Key: n, Val: true}
+	})
+	joins := JoinSorted(cmp.Compare[int], squares, evens)
+	require.True(t, joins.isInfinite())
+	assert.Equal(t, []int{4, 16, 36}, Map(joins.Limit(3), func(p item.Pair[int, item.Joined[int, bool]]) int {
+		return p.Val.Left
+	}).ToSlice())
+
+	// the inner join ends when any of the inputs ends
+	assert.Equal(t, 2, JoinSorted(cmp.Compare[int], squares, Of(
+		item.Pair[int, bool]{Key: 2}, item.Pair[int, bool]{Key: 5},
+	)).Count())
+}
@@ -0,0 +1,203 @@
+package stream
+
+import (
+	"fmt"
+
+	"github.com/mariomac/gostream/item"
+)
+
+// ReduceByKey returns a stream with a pair for each distinct Key of the input stream, whose Val
+// is the result of reducing all the values for that Key with the provided associative
+// accumulator function. The pairs are emitted in order of first appearance of each Key.
+// This function panics if the input stream is infinite.
+func ReduceByKey[K comparable, V any](
+	input Stream[item.Pair[K, V]], accumulator func(a, b V) V,
+) Stream[item.Pair[K, V]] {
+	reduced := AggregateByKey(input, item.None[V](), func(acc item.Optional[V], v V) item.Optional[V] {
+		if acc.Present {
+			return item.Some(accumulator(acc.Val, v))
+		}
+		return item.Some(v)
+	})
+	return MapValues(reduced, func(acc item.Optional[V]) V {
+		return acc.Val
+	})
+}
+
+// AggregateByKey returns a stream with a pair for each distinct Key of the input stream, whose
+// Val is the result of accumulating all the values for that Key with the provided accumulator
+// function, starting from the init value. The pairs are emitted in order of first appearance
+// of each Key.
+// The init value is copied for each Key, so if it is a reference type (e.g. a slice or a map)
+// it will be shared by all the keys, unless the accumulator function creates a new instance.
+// This function panics if the input stream is infinite.
+func AggregateByKey[K comparable, V, A any](
+	input Stream[item.Pair[K, V]], init A, accumulator func(A, V) A,
+) Stream[item.Pair[K, A]] {
+	assertFinite(input)
+	return &iterableStream[item.Pair[K, A]]{
+		props: input.properties(),
+		supply: func(ex *execution) iterator[item.Pair[K, A]] {
+			var keys []K
+			accs := map[K]A{}
+			next := input.iterator(ex.ordered())
+			for n, ok := next(); ok; n, ok = next() {
+				acc, found := accs[n.Key]
+				if !found {
+					keys = append(keys, n.Key)
+					acc = init
+				}
+				accs[n.Key] = accumulator(acc, n.Val)
+			}
+			return func() (item.Pair[K, A], bool) {
+				if len(keys) == 0 {
+					return finishedIterator[item.Pair[K, A]]()
+				}
+				k := keys[0]
+				keys = keys[1:]
+				return item.Pair[K, A]{Key: k, Val: accs[k]}, true
+			}
+		},
+	}
+}
+
+// AggregateByKeyWith returns a stream with a pair for each Key of the provided StateStore,
+// whose Val is the result of accumulating all the values for that Key with the provided
+// accumulator function, as AggregateByKey does, after the input stream ends. The accumulated
+// values are kept in the store as the input stream is consumed, so a store that is restored
+// (e.g. with RestoreStore, OpenFileStore or CheckpointState) continues the aggregation of a
+// previous run, and the keys that were only aggregated by the previous run are also emitted.
+// The values of each Key can be grouped by an accumulator that appends them to a slice.
+// The pairs are emitted in order of first appearance of each Key in the input stream,
+// followed by the rest of keys of the store, in the order of its All method.
+// If the store returns an error, the stream stops without emitting any pair, and the error is
+// returned by the error-returning terminal operations (e.g. ToSliceErr or ForEachErr).
+// This function panics if the input stream is infinite.
+func AggregateByKeyWith[K comparable, V, A any](
+	input Stream[item.Pair[K, V]], store StateStore[K, A], init A, accumulator func(A, V) A,
+) Stream[item.Pair[K, A]] {
+	assertFinite(input)
+	return &iterableStream[item.Pair[K, A]]{
+		props: input.properties(),
+		supply: func(ex *execution) iterator[item.Pair[K, A]] {
+			var keys []K
+			seen := map[K]struct{}{}
+			next := input.iterator(ex.ordered())
+			for n, ok := next(); ok; n, ok = next() {
+				acc, found := store.Get(n.Key)
+				if !found {
+					acc = init
+				}
+				if err := store.Put(n.Key, accumulator(acc, n.Val)); err != nil {
+					if !ex.fail(fmt.Errorf("storing accumulator for key %v: %w", n.Key, err)) {
+						return finishedIterator[item.Pair[K, A]]
+					}
+					continue
+				}
+				if _, ok := seen[n.Key]; !ok {
+					seen[n.Key] = struct{}{}
+					keys = append(keys, n.Key)
+				}
+			}
+			for k := range store.All() {
+				if _, ok := seen[k]; !ok {
+					keys = append(keys, k)
+				}
+			}
+			return func() (item.Pair[K, A], bool) {
+				for len(keys) > 0 {
+					k := keys[0]
+					keys = keys[1:]
+					// a bounded store might have evicted the key
+					if acc, found := store.Get(k); found {
+						return item.Pair[K, A]{Key: k, Val: acc}, true
+					}
+				}
+				return finishedIterator[item.Pair[K, A]]()
+			}
+		},
+	}
+}
+
+// ScanByKey returns a stream with a pair for each element of the input stream, whose Val is
+// the running value of the accumulator for its Key: the result of accumulating all the values
+// for that Key until that element, starting from the init value. The accumulated values are
+// kept in the provided StateStore, so they outlive the iteration of the stream: a store that
+// is restored (e.g. with RestoreStore or OpenFileStore) continues the aggregation of a previous
+// run. Unlike AggregateByKey, ScanByKey is lazy, so it can be applied to infinite streams.
+// If the store returns an error, the stream stops, and the error is returned by the
+// error-returning terminal operations (e.g. ToSliceErr or ForEachErr).
+func ScanByKey[K comparable, V, A any](
+	input Stream[item.Pair[K, V]], store StateStore[K, A], init A, accumulator func(A, V) A,
+) Stream[item.Pair[K, A]] {
+	return &iterableStream[item.Pair[K, A]]{
+		infinite: input.isInfinite(),
+		props:    input.properties(),
+		supply: func(ex *execution) iterator[item.Pair[K, A]] {
+			next := input.iterator(ex.ordered())
+			return func() (item.Pair[K, A], bool) {
+				for {
+					n, ok := next()
+					if !ok {
+						return finishedIterator[item.Pair[K, A]]()
+					}
+					acc, found := store.Get(n.Key)
+					if !found {
+						acc = init
+					}
+					acc = accumulator(acc, n.Val)
+					if err := store.Put(n.Key, acc); err != nil {
+						if !ex.fail(fmt.Errorf("storing accumulator for key %v: %w", n.Key, err)) {
+							return finishedIterator[item.Pair[K, A]]()
+						}
+						continue
+					}
+					return item.Pair[K, A]{Key: n.Key, Val: acc}, true
+				}
+			}
+		},
+	}
+}
+
+// CountByKey returns a stream with a pair for each distinct Key of the input stream, whose Val
+// is the number of elements with that Key. The pairs are emitted in order of first appearance
+// of each Key.
+// This function panics if the input stream is infinite.
+func CountByKey[K comparable, V any](input Stream[item.Pair[K, V]]) Stream[item.Pair[K, int]] {
+	return AggregateByKey(input, 0, func(count int, _ V) int {
+		return count + 1
+	})
+}
+
+// MapValues returns a stream with the same keys as the input stream, whose values are the
+// result of applying the mapper function to the values of the input stream.
+func MapValues[K comparable, V, W any](input Stream[item.Pair[K, V]], mapper func(V) W) Stream[item.Pair[K, W]] {
+	return Map(input, func(p item.Pair[K, V]) item.Pair[K, W] {
+		return item.Pair[K, W]{Key: p.Key, Val: mapper(p.Val)}
+	})
+}
+
+// FilterKeys returns a stream consisting of the pairs of the input stream whose Key matches
+// the given predicate.
+func FilterKeys[K comparable, V any](input Stream[item.Pair[K, V]], predicate func(K) bool) Stream[item.Pair[K, V]] {
+	return input.Filter(func(p item.Pair[K, V]) bool {
+		return predicate(p.Key)
+	})
+}
+
+// FlatMapValues returns a stream where each pair of the input stream is replaced by a pair
+// with the same Key for each element of the stream that results from applying the mapper
+// function to its value. As in FlatMap, a nil mapped stream is considered empty.
+func FlatMapValues[K comparable, V, W any](
+	input Stream[item.Pair[K, V]], mapper func(V) Stream[W],
+) Stream[item.Pair[K, W]] {
+	return FlatMap(input, func(p item.Pair[K, V]) Stream[item.Pair[K, W]] {
+		values := mapper(p.Val)
+		if values == nil {
+			return nil
+		}
+		return Map(values, func(w W) item.Pair[K, W] {
+			return item.Pair[K, W]{Key: p.Key, Val: w}
+		})
+	})
+}
@@ -0,0 +1,135 @@
+package stream
+
+import (
+	"cmp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mariomac/gostream/item"
+	"github.com/mariomac/gostream/order"
+)
+
+// sales per product
+var sales = Of(
+	kv[int]{Key: "apple", Val: 3},
+	kv[int]{Key: "pear", Val: 1},
+	kv[int]{Key: "apple", Val: 5},
+	kv[int]{Key: "plum", Val: 2},
+	kv[int]{Key: "pear", Val: 4},
+)
+
+func TestReduceByKey(t *testing.T) {
+	assert.Equal(t, []kv[int]{{Key: "apple", Val: 8}, {Key: "pear", Val: 5}, {Key: "plum", Val: 2}},
+		ReduceByKey(sales, item.Add[int]).ToSlice())
+	assert.Empty(t, ReduceByKey(Empty[kv[int]](), item.Add[int]).ToSlice())
+
+	// the result can be chained with other operations
+	assert.Equal(t, []kv[int]{{Key: "apple", Val: 8}, {Key: "pear", Val: 5}},
+		ReduceByKey(sales, item.Add[int]).Sorted(order.Inverse(order.ByVal[string](cmp.Compare[int]))).
+			Limit(2).ToSlice())
+
+	assert.Panics(t, func() {
+		ReduceByKey(Generate(func() kv[int] { return kv[int]{} }), item.Add[int])
+	})
+}
+
+func TestAggregateByKey(t *testing.T) {
+	assert.Equal(t, []kv[string]{{Key: "apple", Val: "35"}, {Key: "pear", Val: "14"}, {Key: "plum", Val: "2"}},
+		AggregateByKey(sales, "", func(acc string, v int) string {
+			return acc + string(rune('0'+v))
+		}).ToSlice())
+}
+
+func TestAggregateByKeyWith(t *testing.T) {
+	totals := NewMemoryStore[string, int]()
+	assert.Equal(t, []kv[int]{{Key: "apple", Val: 8}, {Key: "pear", Val: 5}, {Key: "plum", Val: 2}},
+		AggregateByKeyWith(sales, totals, 0, item.Add[int]).ToSlice())
+
+	// the aggregation continues from the state of the store, which also provides the keys
+	// that are not in the input stream
+	out := AggregateByKeyWith(Of(kv[int]{Key: "plum", Val: 1}, kv[int]{Key: "kiwi", Val: 1}),
+		totals, 0, item.Add[int]).ToSlice()
+	require.Len(t, out, 4)
+	assert.Equal(t, []kv[int]{{Key: "plum", Val: 3}, {Key: "kiwi", Val: 1}}, out[:2])
+	assert.ElementsMatch(t, []kv[int]{{Key: "apple", Val: 8}, {Key: "pear", Val: 5}}, out[2:])
+
+	// grouping the values of each key
+	groups := NewMemoryStore[string, []int]()
+	assert.Equal(t, []kv[[]int]{{Key: "apple", Val: []int{3, 5}}, {Key: "pear", Val: []int{1, 4}}, {Key: "plum", Val: []int{2}}},
+		AggregateByKeyWith(sales, groups, nil, func(g []int, v int) []int {
+			return append(g, v)
+		}).ToSlice())
+}
+
+func TestAggregateByKeyWith_StoreError(t *testing.T) {
+	out, err := AggregateByKeyWith(sales, newFailingStore[string, int]("pear"), 0, item.Add[int]).ToSliceErr()
+	assert.ErrorIs(t, err, errStoreFailed)
+	assert.Empty(t, out)
+
+	// with ContinueOnError, the failing values are discarded
+	out, err = AggregateByKeyWith(sales.ContinueOnError(), newFailingStore[string, int]("pear"), 0, item.Add[int]).ToSliceErr()
+	assert.ErrorIs(t, err, errStoreFailed)
+	assert.Equal(t, []kv[int]{{Key: "apple", Val: 8}, {Key: "plum", Val: 2}}, out)
+}
+
+func TestScanByKey(t *testing.T) {
+	totals := NewMemoryStore[string, int]()
+	assert.Equal(t,
+		[]kv[int]{{Key: "apple", Val: 3}, {Key: "pear", Val: 1}, {Key: "apple", Val: 8}, {Key: "plum", Val: 2}, {Key: "pear", Val: 5}},
+		ScanByKey(sales, totals, 0, item.Add[int]).ToSlice())
+
+	// the aggregation continues from the state of the store
+	assert.Equal(t, []kv[int]{{Key: "plum", Val: 3}, {Key: "kiwi", Val: 1}},
+		ScanByKey(Of(kv[int]{Key: "plum", Val: 1}, kv[int]{Key: "kiwi", Val: 1}), totals, 0, item.Add[int]).ToSlice())
+
+	// it can be applied to infinite streams
+	assert.Equal(t, []kv[int]{{Key: "a", Val: 1}, {Key: "a", Val: 2}, {Key: "a", Val: 3}},
+		ScanByKey(Generate(func() kv[int] { return kv[int]{Key: "a", Val: 1} }),
+			NewMemoryStore[string, int](), 0, item.Add[int]).Limit(3).ToSlice())
+}
+
+func TestScanByKey_StoreError(t *testing.T) {
+	out, err := ScanByKey(sales, newFailingStore[string, int]("pear"), 0, item.Add[int]).ToSliceErr()
+	assert.ErrorIs(t, err, errStoreFailed)
+	assert.Equal(t, []kv[int]{{Key: "apple", Val: 3}}, out)
+}
+
+func TestCountByKey(t *testing.T) {
+	assert.Equal(t, []kv[int]{{Key: "apple", Val: 2}, {Key: "pear", Val: 2}, {Key: "plum", Val: 1}},
+		CountByKey(sales).ToSlice())
+}
+
+func TestMapValues(t *testing.T) {
+	doubled := MapValues(Iterate(kv[int]{Key: "n", Val: 1}, func(p kv[int]) kv[int] {
+		return kv[int]{Key: p.Key, Val: p.Val + 1}
+	}), func(v int) int { return v * 2 })
+	require.True(t, doubled.isInfinite())
+	assert.Equal(t, []kv[int]{{Key: "n", Val: 2}, {Key: "n", Val: 4}}, doubled.Limit(2).ToSlice())
+}
+
+func TestFilterKeys(t *testing.T) {
+	assert.Equal(t, []kv[int]{{Key: "pear", Val: 1}, {Key: "plum", Val: 2}, {Key: "pear", Val: 4}},
+		FilterKeys(sales, func(k string) bool { return strings.HasPrefix(k, "p") }).ToSlice())
+}
+
+func TestFlatMapValues(t *testing.T) {
+	words := Of(kv[string]{Key: "a", Val: "hello world"}, kv[string]{Key: "b", Val: "bye"})
+	assert.Equal(t, []kv[string]{{Key: "a", Val: "hello"}, {Key: "a", Val: "world"}, {Key: "b", Val: "bye"}},
+		FlatMapValues(words, func(v string) Stream[string] {
+			return OfSlice(strings.Fields(v))
+		}).ToSlice())
+}
+
+func TestFlatMapValues_NilStream(t *testing.T) {
+	words := Of(kv[string]{Key: "a", Val: "hello world"}, kv[string]{Key: "b", Val: ""})
+	assert.Equal(t, []kv[string]{{Key: "a", Val: "hello"}, {Key: "a", Val: "world"}},
+		FlatMapValues(words, func(v string) Stream[string] {
+			if v == "" {
+				return nil
+			}
+			return OfSlice(strings.Fields(v))
+		}).ToSlice())
+}
@@ -0,0 +1,85 @@
+package stream
+
+import (
+	"sync"
+)
+
+// closeHandler is a function that is invoked at most once, when the stream is closed
+type closeHandler struct {
+	once    sync.Once
+	handler func()
+}
+
+// close invokes all the close handlers, in the same order as they were registered
+func (m streamProps) close() {
+	for _, c := range m.closers {
+		c.once.Do(c.handler)
+	}
+}
+
+// cleanups release the resources that the stages of a stream allocated for an execution
+type cleanups struct {
+	mu  sync.Mutex
+	fns []func()
+}
+
+// onRelease registers a function that releases a resource used by the execution.
+// The registered functions are invoked when the execution finishes, in the
+// reverse order of registration.
+func (ex *execution) onRelease(fn func()) {
+	ex.cleanups.mu.Lock()
+	ex.cleanups.fns = append(ex.cleanups.fns, fn)
+	ex.cleanups.mu.Unlock()
+}
+
+// release all the resources that have been registered with onRelease
+func (ex *execution) release() {
+	ex.cleanups.mu.Lock()
+	fns := ex.cleanups.fns
+	ex.cleanups.fns = nil
+	ex.cleanups.mu.Unlock()
+	for i := len(fns) - 1; i >= 0; i-- {
+		fns[i]()
+	}
+}
+
+// iterateWithin returns an iterator to the input stream that is executed as part of the
+// parent execution, along with a function that releases its resources and closes the
+// input stream before the parent execution finishes.
+// It is used by the operations that create intermediate streams (e.g. FlatMap).
+func iterateWithin[T any](parent *execution, input Stream[T]) (iterator[T], func()) {
+	ex := parent.fork(parent.ctx)
+	return input.iterator(ex), func() {
+		ex.release()
+		input.properties().close()
+	}
+}
+
+// OnClose returns an equivalent stream with an additional close handler. Close handlers
+// are invoked when the Close method is invoked on the stream or any stream derived from it,
+// or when a terminal operation finishes. Each close handler is invoked at most once, in the
+// same order as they were registered.
+// This function is equivalent to invoking input.OnClose(handler) as method.
+func OnClose[T any](input Stream[T], handler func()) Stream[T] {
+	return input.OnClose(handler)
+}
+
+func (is *iterableStream[T]) OnClose(handler func()) Stream[T] {
+	props := is.props.closingWith(streamProps{
+		closers: []*closeHandler{{handler: handler}},
+	})
+	return &iterableStream[T]{infinite: is.infinite, props: props, supply: is.supply}
+}
+
+// Close the stream, invoking all the close handlers of this stream pipeline.
+// Terminal operations automatically close the stream when they finish, so Close
+// only needs to be explicitly invoked for streams that are not going to be
+// processed by any terminal operation.
+// This function is equivalent to invoking input.Close() as method.
+func Close[T any](input Stream[T]) {
+	input.Close()
+}
+
+func (is *iterableStream[T]) Close() {
+	is.props.close()
+}
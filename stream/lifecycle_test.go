@@ -0,0 +1,138 @@
+package stream
+
+import (
+	"fmt"
+	"iter"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mariomac/gostream/item"
+)
+
+// countingSeq returns an infinite sequence of numbers, and a flag that is set to true
+// when the sequence is stopped
+func countingSeq() (iter.Seq[int], *atomic.Bool) {
+	stopped := &atomic.Bool{}
+	return func(yield func(int) bool) {
+		defer stopped.Store(true)
+		for i := 1; ; i++ {
+			if !yield(i) {
+				return
+			}
+		}
+	}, stopped
+}
+
+func TestOfSeq_StopsOnShortCircuit(t *testing.T) {
+	seq, stopped := countingSeq()
+	first, ok := OfSeq(seq).FindFirst()
+	require.True(t, ok)
+	assert.Equal(t, 1, first)
+	assert.True(t, stopped.Load())
+
+	seq, stopped = countingSeq()
+	assert.True(t, OfSeq(seq).Limit(100).AnyMatch(item.Equals(3)))
+	assert.True(t, stopped.Load())
+
+	seq, stopped = countingSeq()
+	for n := range OfSeq(seq).Seq() {
+		if n == 5 {
+			break
+		}
+	}
+	assert.True(t, stopped.Load())
+}
+
+func TestOfSeq2_StopsOnShortCircuit(t *testing.T) {
+	stopped := false
+	seq := func(yield func(int, string) bool) {
+		defer func() { stopped = true }()
+		for i := 0; ; i++ {
+			if !yield(i, fmt.Sprint(i)) {
+				return
+			}
+		}
+	}
+	assert.Equal(t, map[int]string{0: "0", 1: "1"}, ToMap(OfSeq2(seq).Limit(2)))
+	assert.True(t, stopped)
+}
+
+func TestOnClose(t *testing.T) {
+	var events []string
+	s := Of(1, 2, 3).
+		OnClose(func() { events = append(events, "close 1") }).
+		Map(item.Neg[int]).
+		OnClose(func() { events = append(events, "close 2") }).
+		Filter(func(n int) bool { return n < -1 }).
+		Peek(func(n int) { events = append(events, fmt.Sprint("peek ", n)) })
+
+	assert.Equal(t, []int{-2, -3}, s.ToSlice())
+	assert.Equal(t, []string{"peek -2", "peek -3", "close 1", "close 2"}, events)
+
+	// handlers are not invoked twice
+	s.Close()
+	assert.Len(t, events, 4)
+}
+
+func TestOnClose_ShortCircuit(t *testing.T) {
+	closed := false
+	s := Iterate(1, item.Increment[int]).OnClose(func() { closed = true })
+	first, ok := s.Skip(3).FindFirst()
+	require.True(t, ok)
+	assert.Equal(t, 4, first)
+	assert.True(t, closed)
+}
+
+func TestClose(t *testing.T) {
+	var closed []string
+	a := Of(1, 2).OnClose(func() { closed = append(closed, "a") })
+	b := Of(3, 4).OnClose(func() { closed = append(closed, "b") })
+	c := Concat(a, b).OnClose(func() { closed = append(closed, "c") })
+
+	// closing a derived stream does not close the streams derived from it
+	a.Close()
+	assert.Equal(t, []string{"a"}, closed)
+	c.Close()
+	assert.Equal(t, []string{"a", "b", "c"}, closed)
+}
+
+func TestFlatMap_ClosesMappedStreams(t *testing.T) {
+	var events []string
+	out := FlatMap(Of("a", "b"), func(s string) Stream[string] {
+		return Of(s+"1", s+"2").OnClose(func() {
+			events = append(events, "close "+s)
+		})
+	}).Peek(func(s string) {
+		events = append(events, s)
+	}).ToSlice()
+	assert.Equal(t, []string{"a1", "a2", "b1", "b2"}, out)
+	assert.Equal(t, []string{"a1", "a2", "close a", "b1", "b2", "close b"}, events)
+
+	// mapped streams that are not fully iterated are closed when the terminal finishes
+	events = nil
+	first, ok := FlatMap(Of("a", "b"), func(s string) Stream[string] {
+		return Of(s+"1", s+"2").OnClose(func() {
+			events = append(events, "close "+s)
+		})
+	}).FindFirst()
+	require.True(t, ok)
+	assert.Equal(t, "a1", first)
+	assert.Equal(t, []string{"close a"}, events)
+}
+
+func TestOnClose_Parallel(t *testing.T) {
+	seq, stopped := countingSeq()
+	closed := false
+	assert.True(t, OfSeq(seq).OnClose(func() { closed = true }).
+		Parallel(4).
+		Map(item.Neg[int]).
+		AnyMatch(item.Equals(-10)))
+	assert.True(t, closed)
+	assert.Eventually(t, func() bool {
+		return stopped.Load()
+	}, time.Second, time.Millisecond)
+}
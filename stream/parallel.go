@@ -0,0 +1,237 @@
+package stream
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// Parallel returns an equivalent stream whose subsequent Map, Filter, FlatMap and Peek
+// operations are distributed across the given number of worker goroutines. If workers
+// is zero or negative, the value of runtime.GOMAXPROCS is used.
+// A panic in any of the functions of the parallel operations stops the workers, and is
+// raised again in the goroutine that invokes the terminal operation.
+// This function is equivalent to invoking input.Parallel(workers) as method.
+func Parallel[T any](input Stream[T], workers int) Stream[T] {
+	return input.Parallel(workers)
+}
+
+func (is *iterableStream[T]) Parallel(workers int) Stream[T] {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	props := is.props
+	props.workers = workers
+	return &iterableStream[T]{infinite: is.infinite, props: props, supply: is.supply}
+}
+
+// Sequential returns an equivalent stream whose subsequent operations are executed
+// sequentially in the goroutine that invokes the terminal operation.
+// This function is equivalent to invoking input.Sequential() as method.
+func Sequential[T any](input Stream[T]) Stream[T] {
+	return input.Sequential()
+}
+
+func (is *iterableStream[T]) Sequential() Stream[T] {
+	props := is.props
+	props.workers = 0
+	return &iterableStream[T]{infinite: is.infinite, props: props, supply: is.supply}
+}
+
+// Unordered returns an equivalent stream whose parallel operations can emit their
+// elements as soon as they are processed, without keeping the order of the input.
+// This function is equivalent to invoking input.Unordered() as method.
+func Unordered[T any](input Stream[T]) Stream[T] {
+	return input.Unordered()
+}
+
+func (is *iterableStream[T]) Unordered() Stream[T] {
+	props := is.props
+	props.unordered = true
+	return &iterableStream[T]{infinite: is.infinite, props: props, supply: is.supply}
+}
+
+// parallelJob is an input item along with its position in the input stream
+type parallelJob[T any] struct {
+	seq  uint64
+	item T
+	// panicked is the value of a panic that was recovered while pulling the input item
+	panicked any
+}
+
+// parallelResult contains the items resulting from processing the input item
+// at the seq position
+type parallelResult[T any] struct {
+	seq   uint64
+	items []T
+	// panicked is the value of a panic that was recovered while pulling or processing
+	// the input item. It is raised again by the goroutine that pulls the results.
+	panicked any
+}
+
+// parallelStage returns a stream whose items are the result of invoking the process function
+// over each item of the input stream, distributing the invocations across the worker goroutines
+// of the input stream properties. The process function returns zero or more output items
+// for each input item.
+func parallelStage[IN, OUT any](
+	input Stream[IN], infinite bool, process func(*execution, IN) []OUT,
+) Stream[OUT] {
+	props := input.properties()
+	return &iterableStream[OUT]{
+		infinite: infinite,
+		props:    props,
+		supply: func(ex *execution) iterator[OUT] {
+			return parallelIterator(ex, props, input, process)
+		},
+	}
+}
+
+func parallelIterator[IN, OUT any](
+	ex *execution, props streamProps, input Stream[IN], process func(*execution, IN) []OUT,
+) iterator[OUT] {
+	ctx, cancel := context.WithCancel(ex.ctx)
+	jobs := make(chan parallelJob[IN], props.workers)
+	results := make(chan parallelResult[OUT], props.workers)
+	// bounds the number of items that are being processed or waiting to be emitted,
+	// so an ordered stage does not buffer without limit behind a slow item
+	inFlight := make(chan struct{}, 2*props.workers)
+
+	// the upstream iterator is not safe for concurrent use, so a single
+	// goroutine pulls the input items and distributes them across the workers.
+	// It also releases the upstream resources, as it is the only one using them.
+	go func() {
+		upstream := ex.fork(ctx)
+		defer upstream.release()
+		defer close(jobs)
+		next := input.iterator(upstream)
+		for seq := uint64(0); ; seq++ {
+			select {
+			case inFlight <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			n, ok, panicked := pullRecovering(next)
+			if panicked != nil {
+				// a panic in the upstream stages is forwarded to a worker, as any other job
+				select {
+				case jobs <- parallelJob[IN]{seq: seq, panicked: panicked}:
+				case <-ctx.Done():
+				}
+				return
+			}
+			if !ok {
+				return
+			}
+			select {
+			case jobs <- parallelJob[IN]{seq: seq, item: n}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	wg := sync.WaitGroup{}
+	wg.Add(props.workers)
+	for range props.workers {
+		go func() {
+			defer wg.Done()
+			for {
+				var job parallelJob[IN]
+				var ok bool
+				select {
+				case job, ok = <-jobs:
+					if !ok {
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
+				res := parallelResult[OUT]{seq: job.seq, panicked: job.panicked}
+				if res.panicked == nil {
+					res.items, res.panicked = processRecovering(ex, job.item, process)
+				}
+				if res.panicked != nil {
+					// stop the rest of workers, and report the panic unless the
+					// terminal operation has already finished
+					cancel()
+					select {
+					case results <- res:
+					case <-ex.ctx.Done():
+					}
+					return
+				}
+				select {
+				case results <- res:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	ordered := !props.unordered && !ex.unordered
+	// results that arrived before some of their predecessors, in ordered mode
+	pending := map[uint64][]OUT{}
+	var expected uint64
+	var buffer []OUT
+	return func() (OUT, bool) {
+		for {
+			if len(buffer) > 0 {
+				n := buffer[0]
+				buffer = buffer[1:]
+				return n, true
+			}
+			if ordered {
+				if items, ok := pending[expected]; ok {
+					delete(pending, expected)
+					expected++
+					<-inFlight
+					buffer = items
+					continue
+				}
+			}
+			res, ok := <-results
+			if !ok {
+				cancel()
+				return finishedIterator[OUT]()
+			}
+			if res.panicked != nil {
+				cancel()
+				panic(res.panicked)
+			}
+			if ordered {
+				pending[res.seq] = res.items
+				continue
+			}
+			<-inFlight
+			buffer = res.items
+		}
+	}
+}
+
+// pullRecovering pulls the next item, recovering any panic of the upstream stages
+func pullRecovering[T any](next iterator[T]) (n T, ok bool, panicked any) {
+	defer func() {
+		if p := recover(); p != nil {
+			panicked = p
+		}
+	}()
+	n, ok = next()
+	return n, ok, nil
+}
+
+// processRecovering processes an item, recovering any panic of the process function
+func processRecovering[IN, OUT any](
+	ex *execution, n IN, process func(*execution, IN) []OUT,
+) (items []OUT, panicked any) {
+	defer func() {
+		if p := recover(); p != nil {
+			panicked = p
+		}
+	}()
+	return process(ex, n), nil
+}
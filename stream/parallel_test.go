@@ -0,0 +1,188 @@
+package stream
+
+import (
+	"runtime"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mariomac/gostream/item"
+)
+
+func TestParallel_KeepsOrder(t *testing.T) {
+	input := Iterate(1, item.Increment[int]).Limit(1000).Parallel(8)
+	out := Map(input.Filter(func(n int) bool {
+		return n%2 == 0
+	}), func(n int) string {
+		if n%7 == 0 {
+			// slow down some items to force out-of-order completion
+			time.Sleep(time.Millisecond)
+		}
+		return strconv.Itoa(n)
+	}).ToSlice()
+
+	require.Len(t, out, 500)
+	for i, s := range out {
+		assert.Equal(t, strconv.Itoa((i+1)*2), s)
+	}
+}
+
+func TestParallel_Unordered(t *testing.T) {
+	out := Iterate(1, item.Increment[int]).Limit(1000).
+		Parallel(8).Unordered().
+		Map(func(n int) int {
+			return n * 2
+		}).ToSlice()
+
+	slices.Sort(out)
+	require.Len(t, out, 1000)
+	for i, n := range out {
+		assert.Equal(t, (i+1)*2, n)
+	}
+}
+
+func TestParallel_FlatMapAndPeek(t *testing.T) {
+	var peeked atomic.Int32
+	out := FlatMap(Of(3, 0, 2, 1).Parallel(4).Peek(func(int) {
+		peeked.Add(1)
+	}), func(n int) Stream[int] {
+		return Iterate(1, item.Increment[int]).Limit(n)
+	}).ToSlice()
+
+	assert.Equal(t, []int{1, 2, 3, 1, 2, 1}, out)
+	assert.EqualValues(t, 4, peeked.Load())
+}
+
+func TestParallel_Terminals(t *testing.T) {
+	numbers := Iterate(1, item.Increment[int]).Limit(100).Parallel(4).
+		Map(func(n int) int {
+			return n * 10
+		})
+
+	sum, ok := numbers.Reduce(item.Add[int])
+	require.True(t, ok)
+	assert.Equal(t, 50500, sum)
+	assert.Equal(t, 100, numbers.Count())
+	assert.True(t, numbers.AnyMatch(item.Equals(500)))
+	assert.False(t, numbers.AnyMatch(item.Equals(505)))
+	first, ok := numbers.FindFirst()
+	require.True(t, ok)
+	assert.Equal(t, 10, first)
+}
+
+func TestParallel_Sequential(t *testing.T) {
+	var goroutines []int
+	caller := goroutineID()
+	Of(1, 2, 3).Parallel(4).Sequential().
+		Peek(func(int) {
+			goroutines = append(goroutines, goroutineID())
+		}).ForEach(func(int) {})
+	assert.Equal(t, []int{caller, caller, caller}, goroutines)
+}
+
+func TestParallel_StopsWorkersOnShortCircuit(t *testing.T) {
+	before := runtime.NumGoroutine()
+	var processed atomic.Int64
+	found := Iterate(1, item.Increment[int]).Limit(1_000_000).
+		Parallel(4).
+		Map(func(n int) int {
+			processed.Add(1)
+			return n
+		}).
+		AnyMatch(item.Equals(10))
+	require.True(t, found)
+
+	// all the workers must finish soon after the terminal operation returns
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.LessOrEqual(t, runtime.NumGoroutine(), before)
+	stopped := processed.Load()
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, stopped, processed.Load())
+	assert.Less(t, stopped, int64(1000))
+}
+
+func TestParallel_Panic(t *testing.T) {
+	before := runtime.NumGoroutine()
+	panicking := func(n int) int {
+		if n == 50 {
+			panic("boom at " + strconv.Itoa(n))
+		}
+		return n
+	}
+	assert.PanicsWithValue(t, "boom at 50", func() {
+		Iterate(1, item.Increment[int]).Limit(100).Parallel(4).Map(panicking).ToSlice()
+	})
+	assert.PanicsWithValue(t, "boom at 50", func() {
+		Iterate(1, item.Increment[int]).Limit(100).Parallel(4).Unordered().Map(panicking).ForEach(func(int) {})
+	})
+	// a panic of a parallel stage is also raised through the following parallel stages
+	assert.PanicsWithValue(t, "boom at 50", func() {
+		Iterate(1, item.Increment[int]).Limit(100).Parallel(4).Map(panicking).
+			Filter(func(int) bool { return true }).ToSlice()
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before)
+}
+
+// goroutineID extracts the identifier of the current goroutine from its stack trace
+func goroutineID() int {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	// stack trace starts with "goroutine <id> [running]:"
+	buf = buf[len("goroutine "):]
+	id, _ := strconv.Atoi(string(buf[:slices.Index(buf, ' ')]))
+	return id
+}
+
+func TestFindAny(t *testing.T) {
+	_, ok := Empty[int]().FindAny()
+	assert.False(t, ok)
+	_, ok = Empty[int]().Parallel(4).Filter(item.IsZero[int]).FindAny()
+	assert.False(t, ok)
+
+	// in sequential streams, it behaves as FindFirst
+	n, ok := Of(5, 6, 7).Filter(item.GreaterThan(5)).FindAny()
+	require.True(t, ok)
+	assert.Equal(t, 6, n)
+
+	// in parallel streams, it does not wait for the first element in order
+	n, ok = Iterate(1, item.Increment[int]).Limit(1000).Parallel(8).
+		Filter(func(n int) bool {
+			if n == 1 {
+				time.Sleep(time.Second)
+			}
+			return n%2 == 1
+		}).
+		FindAny()
+	require.True(t, ok)
+	assert.NotEqual(t, 1, n)
+	assert.Equal(t, 1, n%2)
+}
+
+func TestFindAny_KeepsOrderBeforeLimit(t *testing.T) {
+	for range 10 {
+		n, ok := Iterate(1, item.Increment[int]).Limit(1000).Parallel(8).
+			Map(func(n int) int {
+				if n == 1 {
+					time.Sleep(10 * time.Millisecond)
+				}
+				return n
+			}).
+			Limit(1).
+			FindAny()
+		require.True(t, ok)
+		assert.Equal(t, 1, n)
+	}
+}
@@ -0,0 +1,223 @@
+package stream
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/mariomac/gostream/item"
+)
+
+// SessionWindows returns a stream that groups the elements of the input stream into sessions,
+// for each key returned by the keyFn function. A session of a key ends when no element with
+// that key arrives within gap after its last element. Then, it is emitted as a pair with the
+// key and the elements of the session, in order of arrival.
+// If the input stream has an event time attached with WithEventTime, sessions are measured in
+// event time: a session ends when the watermark passes the event time of its last element plus
+// gap, and the late elements that would belong to an ended session are discarded. Then, replaying
+// historical data with OfSlice returns the same sessions as receiving it live with OfChannel.
+// Otherwise, sessions are measured in processing time: the time when each element is received,
+// which is mostly useful for streams created from a channel.
+// When the input stream ends, the sessions that are still open are emitted immediately.
+// It panics if gap is not positive.
+func SessionWindows[T any, K comparable](input Stream[T], keyFn func(T) K, gap time.Duration) Stream[item.Pair[K, []T]] {
+	if gap <= 0 {
+		panic(fmt.Sprintf("session gap must be positive. Got: %v", gap))
+	}
+	if _, ok := input.properties().eventTime.(eventTime[T]); ok {
+		return eventTimeSessions(input, keyFn, gap)
+	}
+	return &iterableStream[item.Pair[K, []T]]{
+		infinite: input.isInfinite(),
+		props:    input.properties(),
+		supply: func(ex *execution) iterator[item.Pair[K, []T]] {
+			clk := input.properties().clock()
+			in := pullAsync(ex, input, clk)
+			s := keyedSessions[K, T]{open: map[K]*keyedSession[T]{}, deadlines: newSessionDeadlines[K]()}
+			var timeout <-chan time.Time
+			// deadline that the timeout has been armed for
+			var timeoutAt time.Time
+			return func() (item.Pair[K, []T], bool) {
+				for len(s.closed) == 0 {
+					select {
+					case t, ok := <-in:
+						if !ok {
+							if len(s.open) == 0 {
+								return finishedIterator[item.Pair[K, []T]]()
+							}
+							s.closeUntil(endOfTime)
+							continue
+						}
+						t.repanic()
+						s.closeUntil(t.ts)
+						s.add(keyFn(t.val), t.val, t.ts.Add(gap))
+					case <-timeout:
+						timeout = nil
+						s.closeUntil(clk.Now())
+					case <-ex.ctx.Done():
+						return finishedIterator[item.Pair[K, []T]]()
+					}
+					if deadline, ok := s.nextDeadline(); ok && (timeout == nil || !timeoutAt.Equal(deadline)) {
+						timeoutAt = deadline
+						timeout = clk.After(deadline.Sub(clk.Now()))
+					}
+				}
+				session := s.closed[0]
+				s.closed = s.closed[1:]
+				return session, true
+			}
+		},
+	}
+}
+
+// eventTimeSessions keeps the event-time session windows of each key, which are emitted
+// as the watermark passes their end
+func eventTimeSessions[T any, K comparable](input Stream[T], keyFn func(T) K, gap time.Duration) Stream[item.Pair[K, []T]] {
+	et := eventTimeOf(input)
+	return &iterableStream[item.Pair[K, []T]]{
+		infinite: input.isInfinite(),
+		props:    input.properties(),
+		supply: func(ex *execution) iterator[item.Pair[K, []T]] {
+			next := input.iterator(ex.ordered())
+			tracker := newEventTracker(et)
+			sessions := map[K]*eventWindows[T]{}
+			deadlines := newSessionDeadlines[K]()
+			var closed []item.Pair[K, []T]
+			ended := false
+			return func() (item.Pair[K, []T], bool) {
+				for len(closed) == 0 {
+					if ended {
+						return finishedIterator[item.Pair[K, []T]]()
+					}
+					n, ok := next()
+					if !ok {
+						ended = true
+						tracker.end()
+					} else {
+						watermark := tracker.watermark
+						ts, _ := tracker.observe(n)
+						key := keyFn(n)
+						ks, found := sessions[key]
+						if !found {
+							ks = &eventWindows[T]{assigner: SessionEventWindows(gap)}
+							sessions[key] = ks
+						}
+						// the session of the element ends, at least, gap after its event time
+						if ks.add(n, ts, watermark) {
+							deadlines.push(key, ts.Add(gap))
+						} else if len(ks.windows) == 0 {
+							delete(sessions, key)
+						}
+					}
+					var fired []item.Pair[K, EventWindow[T]]
+					for deadlines.len() > 0 && !deadlines.peek().at.After(tracker.watermark) {
+						key := deadlines.pop().key
+						ks, found := sessions[key]
+						if !found {
+							continue
+						}
+						for _, w := range ks.fire(tracker.watermark) {
+							fired = append(fired, item.Pair[K, EventWindow[T]]{Key: key, Val: w})
+						}
+						if len(ks.windows) == 0 {
+							delete(sessions, key)
+						}
+					}
+					// the sessions are emitted in order of end
+					slices.SortStableFunc(fired, func(a, b item.Pair[K, EventWindow[T]]) int {
+						return a.Val.End.Compare(b.Val.End)
+					})
+					for _, f := range fired {
+						closed = append(closed, item.Pair[K, []T]{Key: f.Key, Val: f.Val.Elements})
+					}
+				}
+				session := closed[0]
+				closed = closed[1:]
+				return session, true
+			}
+		},
+	}
+}
+
+// keyedSessions keeps the open processing-time sessions of each key, as well as the closed
+// sessions that are pending to be emitted
+type keyedSessions[K comparable, T any] struct {
+	open      map[K]*keyedSession[T]
+	deadlines *sessionDeadlines[K]
+	closed    []item.Pair[K, []T]
+}
+
+type keyedSession[T any] struct {
+	items    []T
+	deadline time.Time
+}
+
+func (s *keyedSessions[K, T]) add(key K, val T, deadline time.Time) {
+	ks, ok := s.open[key]
+	if !ok {
+		ks = &keyedSession[T]{}
+		s.open[key] = ks
+	}
+	ks.items = append(ks.items, val)
+	ks.deadline = deadline
+	s.deadlines.push(key, deadline)
+}
+
+// nextDeadline returns the earliest deadline of the open sessions, discarding the
+// deadlines of the sessions that have been extended or closed since they were pushed
+func (s *keyedSessions[K, T]) nextDeadline() (time.Time, bool) {
+	for s.deadlines.len() > 0 {
+		d := s.deadlines.peek()
+		if ks, ok := s.open[d.key]; ok && ks.deadline.Equal(d.at) {
+			return d.at, true
+		}
+		s.deadlines.pop()
+	}
+	return time.Time{}, false
+}
+
+// closeUntil closes all the sessions whose deadline is not after the provided time
+func (s *keyedSessions[K, T]) closeUntil(now time.Time) {
+	for deadline, ok := s.nextDeadline(); ok && !deadline.After(now); deadline, ok = s.nextDeadline() {
+		key := s.deadlines.pop().key
+		s.closed = append(s.closed, item.Pair[K, []T]{Key: key, Val: s.open[key].items})
+		delete(s.open, key)
+	}
+}
+
+// sessionDeadlines sorts the keys of the sessions by their deadline. Keys with the same
+// deadline are sorted in order of insertion, so the sessions are emitted deterministically.
+type sessionDeadlines[K comparable] struct {
+	queue *priorityQueue[sessionDeadline[K]]
+	seq   int
+}
+
+type sessionDeadline[K comparable] struct {
+	key K
+	at  time.Time
+	seq int
+}
+
+func newSessionDeadlines[K comparable]() *sessionDeadlines[K] {
+	return &sessionDeadlines[K]{queue: newPriorityQueue(func(a, b sessionDeadline[K]) int {
+		return cmp.Or(a.at.Compare(b.at), cmp.Compare(a.seq, b.seq))
+	})}
+}
+
+func (sd *sessionDeadlines[K]) push(key K, at time.Time) {
+	sd.seq++
+	sd.queue.push(sessionDeadline[K]{key: key, at: at, seq: sd.seq})
+}
+
+func (sd *sessionDeadlines[K]) peek() sessionDeadline[K] {
+	return sd.queue.peek()
+}
+
+func (sd *sessionDeadlines[K]) pop() sessionDeadline[K] {
+	return sd.queue.pop()
+}
+
+func (sd *sessionDeadlines[K]) len() int {
+	return sd.queue.len()
+}
@@ -0,0 +1,143 @@
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mariomac/gostream/clock"
+	"github.com/mariomac/gostream/item"
+)
+
+func parity(n int) int {
+	return n % 2
+}
+
+func TestSessionWindows(t *testing.T) {
+	clk := clock.NewManual(epoch)
+	sessions := SessionWindows(OfSeq(arrivals(clk,
+		arrival{at: 0, val: 1},
+		arrival{at: time.Second, val: 2},
+		arrival{at: 2 * time.Second, val: 3},
+		// the session of the even numbers ended at 6s
+		arrival{at: 6 * time.Second, val: 4},
+		// the session of the odd numbers ended at 7s
+		arrival{at: 8 * time.Second, val: 5},
+	)).WithClock(clk), parity, 5*time.Second)
+	assert.Equal(t, []item.Pair[int, []int]{
+		{Key: 0, Val: []int{2}},
+		{Key: 1, Val: []int{1, 3}},
+		// the open sessions are emitted when the input ends
+		{Key: 0, Val: []int{4}},
+		{Key: 1, Val: []int{5}},
+	}, sessions.ToSlice())
+}
+
+func TestSessionWindows_Timer(t *testing.T) {
+	clk := clock.NewManual(epoch)
+	src := make(chan int)
+	out := make(chan item.Pair[int, []int])
+	go func() {
+		SessionWindows(OfChannel(src).WithClock(clk), parity, 5*time.Second).
+			ForEach(func(s item.Pair[int, []int]) {
+				out <- s
+			})
+		close(out)
+	}()
+	src <- 1
+	waitTimers(t, clk, 1)
+	clk.Advance(5 * time.Second)
+	assert.Equal(t, item.Pair[int, []int]{Key: 1, Val: []int{1}}, <-out)
+	src <- 2
+	waitTimers(t, clk, 1)
+	// the open session is emitted when the input ends, without waiting for the timer
+	close(src)
+	assert.Equal(t, item.Pair[int, []int]{Key: 0, Val: []int{2}}, <-out)
+	_, ok := <-out
+	assert.False(t, ok)
+}
+
+// user activity, with out-of-order events
+var activity = []event{
+	ev("u1", 1), ev("u2", 2), ev("u1", 4), ev("u2", 12), ev("u1", 11),
+	// late: its session would have ended before the watermark
+	ev("u1", 3),
+	ev("u2", 30),
+}
+
+func eventSessions(input Stream[event]) []item.Pair[string, []event] {
+	return SessionWindows(input.WithEventTime(eventTimeOfEvent, BoundedOutOfOrderness(2*time.Second)),
+		func(e event) string { return e.name }, 5*time.Second).ToSlice()
+}
+
+func TestSessionWindows_EventTime(t *testing.T) {
+	expected := []item.Pair[string, []event]{
+		{Key: "u2", Val: []event{ev("u2", 2)}},
+		{Key: "u1", Val: []event{ev("u1", 1), ev("u1", 4)}},
+		{Key: "u1", Val: []event{ev("u1", 11)}},
+		{Key: "u2", Val: []event{ev("u2", 12)}},
+		{Key: "u2", Val: []event{ev("u2", 30)}},
+	}
+	assert.Equal(t, expected, eventSessions(OfSlice(activity)))
+
+	live := make(chan event)
+	go func() {
+		for _, a := range activity {
+			live <- a
+		}
+		close(live)
+	}()
+	assert.Equal(t, expected, eventSessions(OfChannel(live)))
+}
+
+func TestSessionWindows_ExactGap(t *testing.T) {
+	// the elements of each user that are exactly gap apart belong to different sessions
+	visits := []event{ev("u1", 0), ev("u2", 1), ev("u1", 3), ev("u2", 6), ev("u1", 8), ev("u1", 13)}
+	expected := []item.Pair[string, []event]{
+		{Key: "u2", Val: []event{ev("u2", 1)}},
+		{Key: "u1", Val: []event{ev("u1", 0), ev("u1", 3)}},
+		{Key: "u2", Val: []event{ev("u2", 6)}},
+		{Key: "u1", Val: []event{ev("u1", 8)}},
+		{Key: "u1", Val: []event{ev("u1", 13)}},
+	}
+	for _, tc := range []struct {
+		name  string
+		input func() Stream[event]
+	}{{
+		name: "processing time",
+		input: func() Stream[event] {
+			clk := clock.NewManual(epoch)
+			return OfSeq(func(yield func(event) bool) {
+				for _, v := range visits {
+					clk.Set(atSecond(v.sec))
+					if !yield(v) {
+						return
+					}
+				}
+			}).WithClock(clk)
+		},
+	}, {
+		name: "event time",
+		input: func() Stream[event] {
+			return OfSlice(visits).WithEventTime(eventTimeOfEvent, BoundedOutOfOrderness(0))
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, expected, SessionWindows(tc.input(), func(e event) string { return e.name },
+				5*time.Second).ToSlice())
+		})
+	}
+}
+
+func TestSessionWindows_InputPanic(t *testing.T) {
+	assert.PanicsWithValue(t, "boom", func() {
+		SessionWindows(Map(Of(1, 2, 3), panicsOn(2)), parity, time.Hour).ToSlice()
+	})
+}
+
+func TestSessionWindows_Panics(t *testing.T) {
+	assert.Panics(t, func() {
+		SessionWindows(Of(1, 2), parity, 0)
+	})
+}
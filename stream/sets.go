@@ -0,0 +1,117 @@
+package stream
+
+// Union returns a stream with the distinct elements that are in any of the a or b streams,
+// in order of appearance: first the elements of a, then the elements of b that are not in a.
+// It processes both streams lazily, keeping the already emitted elements in memory. The
+// result is infinite if any of the input streams is infinite.
+// For large streams that are already sorted, UnionSorted does not require keeping the
+// elements in memory.
+func Union[T comparable](a, b Stream[T]) Stream[T] {
+	distinct := Distinct(Concat(a, b))
+	return &iterableStream[T]{
+		infinite: a.isInfinite() || b.isInfinite(),
+		props:    distinct.properties(),
+		supply:   distinct.iterator,
+	}
+}
+
+// Intersect returns a stream with the distinct elements of the a stream that are also in the
+// b stream, in order of appearance.
+// The b stream is entirely loaded into memory when the returned stream is iterated, while the
+// a stream is processed lazily. The result is infinite if the a stream is infinite.
+// For large streams that are already sorted, IntersectSorted does not require keeping the
+// elements in memory.
+// This function panics if the b stream is infinite.
+func Intersect[T comparable](a, b Stream[T]) Stream[T] {
+	return filterBySet(a, b, true)
+}
+
+// Except returns a stream with the distinct elements of the a stream that are not in the
+// b stream, in order of appearance.
+// The b stream is entirely loaded into memory when the returned stream is iterated, while the
+// a stream is processed lazily. The result is infinite if the a stream is infinite.
+// For large streams that are already sorted, ExceptSorted does not require keeping the
+// elements in memory.
+// This function panics if the b stream is infinite.
+func Except[T comparable](a, b Stream[T]) Stream[T] {
+	return filterBySet(a, b, false)
+}
+
+// SymmetricDiff returns a stream with the distinct elements that are only in one of the a
+// or b streams, in order of appearance: first the elements of a that are not in b, then the
+// elements of b that are not in a.
+// Both streams are entirely loaded into memory when the returned stream is iterated.
+// For large streams that are already sorted, SymmetricDiffSorted does not require keeping the
+// elements in memory.
+// This function panics if any of the input streams is infinite.
+func SymmetricDiff[T comparable](a, b Stream[T]) Stream[T] {
+	assertFinite(a)
+	assertFinite(b)
+	return &iterableStream[T]{
+		props: a.properties().closingWith(b.properties()),
+		supply: func(ex *execution) iterator[T] {
+			inA, orderA := collectSet(ex, a)
+			inB, orderB := collectSet(ex, b)
+			var diff []T
+			for _, n := range orderA {
+				if _, ok := inB[n]; !ok {
+					diff = append(diff, n)
+				}
+			}
+			for _, n := range orderB {
+				if _, ok := inA[n]; !ok {
+					diff = append(diff, n)
+				}
+			}
+			return sliceIterator(diff)
+		},
+	}
+}
+
+// filterBySet returns the distinct elements of the a stream whose membership to the b stream
+// equals to the member argument
+func filterBySet[T comparable](a, b Stream[T], member bool) Stream[T] {
+	assertFinite(b)
+	return &iterableStream[T]{
+		infinite: a.isInfinite(),
+		props:    a.properties().closingWith(b.properties()),
+		supply: func(ex *execution) iterator[T] {
+			var inB map[T]struct{}
+			next := a.iterator(ex)
+			emitted := map[T]struct{}{}
+			return func() (T, bool) {
+				if inB == nil {
+					inB, _ = collectSet(ex, b)
+				}
+				for {
+					n, ok := next()
+					if !ok {
+						return finishedIterator[T]()
+					}
+					if _, ok := emitted[n]; ok {
+						continue
+					}
+					if _, ok := inB[n]; ok == member {
+						emitted[n] = struct{}{}
+						return n, true
+					}
+				}
+			}
+		},
+	}
+}
+
+// collectSet returns the distinct elements of the input stream, as a set and as a slice
+// in order of appearance
+func collectSet[T comparable](ex *execution, input Stream[T]) (map[T]struct{}, []T) {
+	set := map[T]struct{}{}
+	var elems []T
+	next := input.iterator(ex)
+	for n, ok := next(); ok; n, ok = next() {
+		if _, ok := set[n]; !ok {
+			set[n] = struct{}{}
+			elems = append(elems, n)
+		}
+	}
+	return set, elems
+}
@@ -0,0 +1,45 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mariomac/gostream/item"
+)
+
+func TestUnion(t *testing.T) {
+	assert.Equal(t, []int{3, 1, 2, 5, 4}, Union(Of(3, 1, 3, 2), Of(5, 1, 4, 4)).ToSlice())
+	assert.Equal(t, []int{1, 2}, Union(Empty[int](), Of(1, 2, 1)).ToSlice())
+
+	union := Union(Of(-1, -2), Iterate(1, item.Increment[int]))
+	require.True(t, union.isInfinite())
+	assert.Equal(t, []int{-1, -2, 1, 2}, union.Limit(4).ToSlice())
+}
+
+func TestIntersect(t *testing.T) {
+	assert.Equal(t, []int{3, 1}, Intersect(Of(3, 1, 3, 2), Of(5, 1, 3, 3)).ToSlice())
+	assert.Empty(t, Intersect(Of(1, 2), Empty[int]()).ToSlice())
+
+	evens := Intersect(Iterate(1, item.Increment[int]), Of(8, 2, 4, 6))
+	require.True(t, evens.isInfinite())
+	assert.Equal(t, []int{2, 4, 6}, evens.Limit(3).ToSlice())
+	assert.Panics(t, func() {
+		Intersect(Of(1), Iterate(1, item.Increment[int]))
+	})
+}
+
+func TestExcept(t *testing.T) {
+	assert.Equal(t, []int{2, 4}, Except(Of(3, 2, 1, 2, 4), Of(5, 1, 3)).ToSlice())
+	assert.Equal(t, []int{1, 2}, Except(Of(1, 2, 1), Empty[int]()).ToSlice())
+	assert.Empty(t, Except(Of(1, 2), Of(2, 1)).ToSlice())
+}
+
+func TestSymmetricDiff(t *testing.T) {
+	assert.Equal(t, []int{3, 2, 5, 4}, SymmetricDiff(Of(3, 1, 3, 2), Of(5, 1, 4, 4)).ToSlice())
+	assert.Empty(t, SymmetricDiff(Of(1, 2), Of(2, 1)).ToSlice())
+	assert.Panics(t, func() {
+		SymmetricDiff(Of(1), Iterate(1, item.Increment[int]))
+	})
+}
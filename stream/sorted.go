@@ -0,0 +1,160 @@
+package stream
+
+import (
+	"github.com/mariomac/gostream/order"
+)
+
+// MergeSorted returns a stream that lazily merges the elements of the input streams, which must
+// be already sorted according to the provided order.Comparator, into a single sorted stream.
+// It never keeps more than one element of each input stream in memory, so it can merge infinite
+// streams as well as long-lived streams created from a channel. However, an element can't be
+// emitted until all the input streams have provided an element, or have ended.
+// Equal elements are emitted in the order of the input streams they come from.
+func MergeSorted[T any](comparator order.Comparator[T], streams ...Stream[T]) Stream[T] {
+	infinite := false
+	var props streamProps
+	for i, s := range streams {
+		infinite = infinite || s.isInfinite()
+		if i == 0 {
+			props = s.properties()
+		} else {
+			props = props.closingWith(s.properties())
+		}
+	}
+	return &iterableStream[T]{
+		infinite: infinite,
+		props:    props,
+		supply: func(ex *execution) iterator[T] {
+			ord := ex.ordered()
+			inputs := make([]iterator[T], 0, len(streams))
+			for _, s := range streams {
+				inputs = append(inputs, s.iterator(ord))
+			}
+			return mergeIterators(inputs, comparator)
+		},
+	}
+}
+
+// UnionSorted returns a sorted stream with the distinct elements that are in any of the a or b
+// streams, which must be already sorted according to the provided order.Comparator.
+// Elements are considered equal when the comparator returns zero for them. When an element is
+// in both streams, the element from the a stream is emitted.
+// It processes both streams lazily, in a single pass and without buffering them. The result is
+// infinite if any of the input streams is infinite.
+func UnionSorted[T any](comparator order.Comparator[T], a, b Stream[T]) Stream[T] {
+	return mergeSets(comparator, a, b, setMembership{onlyA: true, onlyB: true, both: true},
+		a.isInfinite() || b.isInfinite())
+}
+
+// IntersectSorted returns a sorted stream with the distinct elements that are in both the a and
+// b streams, which must be already sorted according to the provided order.Comparator.
+// Elements are considered equal when the comparator returns zero for them, and the element from
+// the a stream is emitted.
+// It processes both streams lazily, in a single pass and without buffering them. The result is
+// finite if any of the input streams is finite.
+func IntersectSorted[T any](comparator order.Comparator[T], a, b Stream[T]) Stream[T] {
+	return mergeSets(comparator, a, b, setMembership{both: true},
+		a.isInfinite() && b.isInfinite())
+}
+
+// ExceptSorted returns a sorted stream with the distinct elements of the a stream that are not
+// in the b stream. Both streams must be already sorted according to the provided order.Comparator.
+// Elements are considered equal when the comparator returns zero for them.
+// It processes both streams lazily, in a single pass and without buffering them. The result is
+// infinite if the a stream is infinite.
+func ExceptSorted[T any](comparator order.Comparator[T], a, b Stream[T]) Stream[T] {
+	return mergeSets(comparator, a, b, setMembership{onlyA: true}, a.isInfinite())
+}
+
+// SymmetricDiffSorted returns a sorted stream with the distinct elements that are only in one of
+// the a or b streams, which must be already sorted according to the provided order.Comparator.
+// Elements are considered equal when the comparator returns zero for them.
+// It processes both streams lazily, in a single pass and without buffering them. The result is
+// infinite if any of the input streams is infinite.
+func SymmetricDiffSorted[T any](comparator order.Comparator[T], a, b Stream[T]) Stream[T] {
+	return mergeSets(comparator, a, b, setMembership{onlyA: true, onlyB: true},
+		a.isInfinite() || b.isInfinite())
+}
+
+// setMembership specifies which elements of a set operation are emitted, according to
+// the input sets they belong to
+type setMembership struct {
+	onlyA, onlyB, both bool
+}
+
+func mergeSets[T any](comparator order.Comparator[T], a, b Stream[T], emit setMembership, infinite bool) Stream[T] {
+	return &iterableStream[T]{
+		infinite: infinite,
+		props:    a.properties().closingWith(b.properties()),
+		supply: func(ex *execution) iterator[T] {
+			ord := ex.ordered()
+			nextA := dedupSorted(a.iterator(ord), comparator)
+			nextB := dedupSorted(b.iterator(ord), comparator)
+			var na, nb T
+			var okA, okB bool
+			// the inputs are pulled when their previous element has been consumed, and
+			// not before, so the merge does not block waiting for elements that it does not need yet
+			pullA, pullB := true, true
+			return func() (T, bool) {
+				for {
+					if pullA {
+						na, okA = nextA()
+						pullA = false
+					}
+					if pullB {
+						nb, okB = nextB()
+						pullB = false
+					}
+					switch {
+					case !okA && (!okB || !emit.onlyB), !okB && !emit.onlyA:
+						// no more elements can be emitted
+						return finishedIterator[T]()
+					case !okB:
+						pullA = true
+						return na, true
+					case !okA:
+						pullB = true
+						return nb, true
+					}
+					switch c := comparator(na, nb); {
+					case c < 0:
+						pullA = true
+						if emit.onlyA {
+							return na, true
+						}
+					case c > 0:
+						pullB = true
+						if emit.onlyB {
+							return nb, true
+						}
+					default:
+						pullA, pullB = true, true
+						if emit.both {
+							return na, true
+						}
+					}
+				}
+			}
+		},
+	}
+}
+
+// dedupSorted returns an iterator that discards the consecutive elements of the input that
+// are equal, according to the comparator, to the last returned element
+func dedupSorted[T any](next iterator[T], comparator order.Comparator[T]) iterator[T] {
+	var last T
+	started := false
+	return func() (T, bool) {
+		for {
+			n, ok := next()
+			if !ok {
+				return n, false
+			}
+			if !started || comparator(last, n) != 0 {
+				started = true
+				last = n
+				return n, true
+			}
+		}
+	}
+}
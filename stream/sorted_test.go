@@ -0,0 +1,144 @@
+package stream
+
+import (
+	"cmp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mariomac/gostream/item"
+	"github.com/mariomac/gostream/order"
+)
+
+func TestMergeSorted(t *testing.T) {
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9},
+		MergeSorted(cmp.Compare[int], Of(1, 4, 7), Of(2, 5, 8, 9), Of(3, 6)).ToSlice())
+	assert.Equal(t, []int{9, 8, 5, 3, 1},
+		MergeSorted(order.Inverse(cmp.Compare[int]), Of(9, 5, 1), Empty[int](), Of(8, 3)).ToSlice())
+	assert.Empty(t, MergeSorted[int](cmp.Compare[int]).ToSlice())
+	assert.Equal(t, []int{1, 2}, MergeSorted(cmp.Compare[int], Of(1, 2)).ToSlice())
+}
+
+func TestMergeSorted_KeepsOrderOfInputs(t *testing.T) {
+	pair := func(k int, v string) item.Pair[int, string] {
+		return item.Pair[int, string]{Key: k, Val: v}
+	}
+	merged := MergeSorted(order.ByKey[int, string](cmp.Compare[int]),
+		Of(pair(1, "a1"), pair(2, "a2")),
+		Of(pair(1, "b1"), pair(2, "b2")))
+	assert.Equal(t, []item.Pair[int, string]{pair(1, "a1"), pair(1, "b1"), pair(2, "a2"), pair(2, "b2")},
+		merged.ToSlice())
+}
+
+func TestMergeSorted_Infinite(t *testing.T) {
+	evens := Iterate(0, func(n int) int { return n + 2 })
+	odds := Iterate(1, func(n int) int { return n + 2 })
+	merged := MergeSorted(cmp.Compare[int], evens, odds)
+	require.True(t, merged.isInfinite())
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5}, merged.Limit(6).ToSlice())
+}
+
+func TestMergeSorted_Channels(t *testing.T) {
+	partitions := []chan int{make(chan int), make(chan int)}
+	out := make(chan int)
+	go func() {
+		MergeSorted(cmp.Compare[int], OfChannel(partitions[0]), OfChannel(partitions[1])).
+			ForEach(func(n int) {
+				out <- n
+			})
+		close(out)
+	}()
+	partitions[0] <- 1
+	partitions[1] <- 3
+	assert.Equal(t, 1, <-out)
+	partitions[0] <- 2
+	assert.Equal(t, 2, <-out)
+	// the merge is not blocked by the partitions that are closed
+	close(partitions[0])
+	assert.Equal(t, 3, <-out)
+	partitions[1] <- 4
+	assert.Equal(t, 4, <-out)
+	close(partitions[1])
+	_, ok := <-out
+	assert.False(t, ok)
+}
+
+func TestMergeSorted_ClosesInputs(t *testing.T) {
+	closed := 0
+	merged := MergeSorted(cmp.Compare[int],
+		Of(1, 3).OnClose(func() { closed++ }),
+		Of(2, 4).OnClose(func() { closed++ }))
+	assert.Equal(t, []int{1, 2}, merged.Limit(2).ToSlice())
+	assert.Equal(t, 2, closed)
+}
+
+func TestSetOperationsSorted(t *testing.T) {
+	a := Of(1, 2, 2, 3, 5, 7, 7)
+	b := Of(2, 3, 3, 4, 7, 8)
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 7, 8}, UnionSorted(cmp.Compare[int], a, b).ToSlice())
+	assert.Equal(t, []int{2, 3, 7}, IntersectSorted(cmp.Compare[int], a, b).ToSlice())
+	assert.Equal(t, []int{1, 5}, ExceptSorted(cmp.Compare[int], a, b).ToSlice())
+	assert.Equal(t, []int{4, 8}, ExceptSorted(cmp.Compare[int], b, a).ToSlice())
+	assert.Equal(t, []int{1, 4, 5, 8}, SymmetricDiffSorted(cmp.Compare[int], a, b).ToSlice())
+
+	assert.Equal(t, []int{1, 2, 3, 5, 7}, UnionSorted(cmp.Compare[int], a, Empty[int]()).ToSlice())
+	assert.Empty(t, IntersectSorted(cmp.Compare[int], Empty[int](), b).ToSlice())
+	assert.Empty(t, ExceptSorted(cmp.Compare[int], Empty[int](), b).ToSlice())
+	assert.Equal(t, []int{2, 3, 4, 7, 8}, SymmetricDiffSorted(cmp.Compare[int], Empty[int](), b).ToSlice())
+}
+
+func TestSetOperationsSorted_EqualsHashBased(t *testing.T) {
+	a := Iterate(0, func(n int) int { return n + 3 }).Limit(100)
+	b := Iterate(0, func(n int) int { return n + 5 }).Limit(70)
+	ops := []struct {
+		sorted func(order.Comparator[int], Stream[int], Stream[int]) Stream[int]
+		hashed func(Stream[int], Stream[int]) Stream[int]
+	}{
+		{UnionSorted[int], Union[int]},
+		{IntersectSorted[int], Intersect[int]},
+		{ExceptSorted[int], Except[int]},
+		{SymmetricDiffSorted[int], SymmetricDiff[int]},
+	}
+	for _, op := range ops {
+		assert.Equal(t, op.hashed(a, b).Sorted(cmp.Compare[int]).ToSlice(),
+			op.sorted(cmp.Compare[int], a, b).ToSlice())
+	}
+}
+
+func TestSetOperationsSorted_Infinite(t *testing.T) {
+	multiplesOf := func(m int) Stream[int] {
+		return Iterate(m, func(n int) int { return n + m })
+	}
+	common := IntersectSorted(cmp.Compare[int], multiplesOf(2), multiplesOf(3))
+	require.True(t, common.isInfinite())
+	assert.Equal(t, []int{6, 12, 18}, common.Limit(3).ToSlice())
+
+	notEven := ExceptSorted(cmp.Compare[int], Iterate(1, item.Increment[int]), multiplesOf(2))
+	require.True(t, notEven.isInfinite())
+	assert.Equal(t, []int{1, 3, 5}, notEven.Limit(3).ToSlice())
+
+	// the intersection ends when any of the inputs ends
+	assert.Equal(t, []int{6}, IntersectSorted(cmp.Compare[int], multiplesOf(3), Of(1, 6, 7)).ToSlice())
+}
+
+func TestSetOperationsSorted_Channels(t *testing.T) {
+	srcA, srcB := make(chan int), make(chan int)
+	out := make(chan int)
+	go func() {
+		UnionSorted(cmp.Compare[int], OfChannel(srcA), OfChannel(srcB)).ForEach(func(n int) {
+			out <- n
+		})
+		close(out)
+	}()
+	srcA <- 1
+	srcB <- 2
+	assert.Equal(t, 1, <-out)
+	srcA <- 2
+	// the element is emitted without waiting for the next elements of the inputs
+	assert.Equal(t, 2, <-out)
+	close(srcA)
+	close(srcB)
+	_, ok := <-out
+	assert.False(t, ok)
+}
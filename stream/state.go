@@ -0,0 +1,219 @@
+package stream
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"maps"
+	"sync"
+
+	"github.com/mariomac/gostream/item"
+)
+
+// StateStore is a key-value store where the stateful operations (e.g. DistinctWith or
+// ScanByKey) keep their state. Since the state lives in the store instead of in the stream
+// iteration, it can be bounded (e.g. NewLRUStore), persisted (e.g. OpenFileStore), or saved
+// and restored with SnapshotStore and RestoreStore.
+// The implementations of this package are safe for concurrent use, so a store can be
+// snapshotted while a stream is updating it.
+type StateStore[K comparable, V any] interface {
+	// Get returns the value for the provided key, and whether the key is in the store.
+	Get(key K) (V, bool)
+	// Put sets the value for the provided key.
+	Put(key K, val V) error
+	// Delete removes the provided key from the store.
+	Delete(key K) error
+	// Clear removes all the keys from the store.
+	Clear() error
+	// Len returns the number of keys in the store.
+	Len() int
+	// All returns an iter.Seq2 over a snapshot of the entries of the store, taken when the
+	// iteration starts.
+	All() iter.Seq2[K, V]
+}
+
+// SnapshotStore writes all the entries of the store into w, encoded with the provided Codec.
+func SnapshotStore[K comparable, V any](store StateStore[K, V], w io.Writer, codec Codec[item.Pair[K, V]]) error {
+	enc := codec.NewEncoder(w)
+	for k, v := range store.All() {
+		if err := enc.Encode(item.Pair[K, V]{Key: k, Val: v}); err != nil {
+			return fmt.Errorf("writing state snapshot: %w", err)
+		}
+	}
+	return nil
+}
+
+// RestoreStore replaces the entries of the store with the entries of a snapshot that was
+// written by SnapshotStore with the same Codec.
+func RestoreStore[K comparable, V any](store StateStore[K, V], r io.Reader, codec Codec[item.Pair[K, V]]) error {
+	if err := store.Clear(); err != nil {
+		return fmt.Errorf("restoring state snapshot: %w", err)
+	}
+	dec := codec.NewDecoder(r)
+	for {
+		var entry item.Pair[K, V]
+		if err := dec.Decode(&entry); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("reading state snapshot: %w", err)
+		}
+		if err := store.Put(entry.Key, entry.Val); err != nil {
+			return fmt.Errorf("restoring state snapshot: %w", err)
+		}
+	}
+}
+
+// MemoryStore is an unbounded StateStore that keeps its entries in memory.
+type MemoryStore[K comparable, V any] struct {
+	mu      sync.RWMutex
+	entries map[K]V
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore[K comparable, V any]() *MemoryStore[K, V] {
+	return &MemoryStore[K, V]{entries: map[K]V{}}
+}
+
+func (m *MemoryStore[K, V]) Get(key K) (V, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	v, ok := m.entries[key]
+	return v, ok
+}
+
+func (m *MemoryStore[K, V]) Put(key K, val V) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = val
+	return nil
+}
+
+func (m *MemoryStore[K, V]) Delete(key K) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+	return nil
+}
+
+func (m *MemoryStore[K, V]) Clear() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	clear(m.entries)
+	return nil
+}
+
+func (m *MemoryStore[K, V]) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.entries)
+}
+
+// All iterates the entries in no particular order.
+func (m *MemoryStore[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.mu.RLock()
+		snapshot := maps.Clone(m.entries)
+		m.mu.RUnlock()
+		for k, v := range snapshot {
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// LRUStore is a StateStore that keeps at most a given number of entries in memory. When a
+// new key is added to a full store, the least recently used key (either by Get or Put) is
+// evicted from it.
+// An operation like DistinctWith can use it to bound its memory usage, at the cost of
+// forgetting the least recently used elements.
+type LRUStore[K comparable, V any] struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[K]*list.Element
+	// recency sorts the entries from the least to the most recently used
+	recency *list.List
+}
+
+// NewLRUStore returns an empty LRUStore that keeps at most capacity entries.
+// It panics if capacity is not positive.
+func NewLRUStore[K comparable, V any](capacity int) *LRUStore[K, V] {
+	if capacity <= 0 {
+		panic(fmt.Sprintf("LRU store capacity must be positive. Got: %d", capacity))
+	}
+	return &LRUStore[K, V]{capacity: capacity, entries: map[K]*list.Element{}, recency: list.New()}
+}
+
+func (l *LRUStore[K, V]) Get(key K) (V, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e, ok := l.entries[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	l.recency.MoveToBack(e)
+	return e.Value.(item.Pair[K, V]).Val, true
+}
+
+func (l *LRUStore[K, V]) Put(key K, val V) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if e, ok := l.entries[key]; ok {
+		e.Value = item.Pair[K, V]{Key: key, Val: val}
+		l.recency.MoveToBack(e)
+		return nil
+	}
+	if l.recency.Len() >= l.capacity {
+		oldest := l.recency.Front()
+		l.recency.Remove(oldest)
+		delete(l.entries, oldest.Value.(item.Pair[K, V]).Key)
+	}
+	l.entries[key] = l.recency.PushBack(item.Pair[K, V]{Key: key, Val: val})
+	return nil
+}
+
+func (l *LRUStore[K, V]) Delete(key K) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if e, ok := l.entries[key]; ok {
+		l.recency.Remove(e)
+		delete(l.entries, key)
+	}
+	return nil
+}
+
+func (l *LRUStore[K, V]) Clear() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	clear(l.entries)
+	l.recency.Init()
+	return nil
+}
+
+func (l *LRUStore[K, V]) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.recency.Len()
+}
+
+// All iterates the entries from the least to the most recently used, so restoring a
+// snapshot of the store keeps the order of eviction.
+func (l *LRUStore[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		l.mu.Lock()
+		snapshot := make([]item.Pair[K, V], 0, l.recency.Len())
+		for e := l.recency.Front(); e != nil; e = e.Next() {
+			snapshot = append(snapshot, e.Value.(item.Pair[K, V]))
+		}
+		l.mu.Unlock()
+		for _, p := range snapshot {
+			if !yield(p.Key, p.Val) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package stream
+
+import (
+	"bytes"
+	"errors"
+	"maps"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mariomac/gostream/item"
+)
+
+var errStoreFailed = errors.New("store failed")
+
+// failingStore is a MemoryStore that fails when the failOn key is put
+type failingStore[K comparable, V any] struct {
+	*MemoryStore[K, V]
+	failOn K
+}
+
+func newFailingStore[K comparable, V any](failOn K) *failingStore[K, V] {
+	return &failingStore[K, V]{MemoryStore: NewMemoryStore[K, V](), failOn: failOn}
+}
+
+func (f *failingStore[K, V]) Put(key K, val V) error {
+	if key == f.failOn {
+		return errStoreFailed
+	}
+	return f.MemoryStore.Put(key, val)
+}
+
+func TestMemoryStore(t *testing.T) {
+	s := NewMemoryStore[string, int]()
+	_, ok := s.Get("a")
+	assert.False(t, ok)
+
+	require.NoError(t, s.Put("a", 1))
+	require.NoError(t, s.Put("b", 2))
+	require.NoError(t, s.Put("a", 3))
+	v, ok := s.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+	assert.Equal(t, 2, s.Len())
+
+	require.NoError(t, s.Delete("b"))
+	require.NoError(t, s.Delete("nonexistent"))
+	assert.Equal(t, map[string]int{"a": 3}, maps.Collect(s.All()))
+
+	require.NoError(t, s.Clear())
+	assert.Zero(t, s.Len())
+}
+
+func TestLRUStore(t *testing.T) {
+	s := NewLRUStore[string, int](3)
+	require.NoError(t, s.Put("a", 1))
+	require.NoError(t, s.Put("b", 2))
+	require.NoError(t, s.Put("c", 3))
+	// "a" becomes the most recently used, so "b" is evicted
+	_, ok := s.Get("a")
+	assert.True(t, ok)
+	require.NoError(t, s.Put("d", 4))
+	_, ok = s.Get("b")
+	assert.False(t, ok)
+	assert.Equal(t, 3, s.Len())
+
+	// updating a key also makes it the most recently used
+	require.NoError(t, s.Put("c", 30))
+	assert.Equal(t, []item.Pair[string, int]{{Key: "a", Val: 1}, {Key: "d", Val: 4}, {Key: "c", Val: 30}},
+		OfSeq2(s.All()).ToSlice())
+
+	require.NoError(t, s.Delete("a"))
+	require.NoError(t, s.Put("e", 5))
+	require.NoError(t, s.Put("f", 6))
+	assert.Equal(t, []item.Pair[string, int]{{Key: "c", Val: 30}, {Key: "e", Val: 5}, {Key: "f", Val: 6}},
+		OfSeq2(s.All()).ToSlice())
+
+	require.NoError(t, s.Clear())
+	assert.Zero(t, s.Len())
+	assert.Panics(t, func() {
+		NewLRUStore[string, int](0)
+	})
+}
+
+func TestSnapshotStore(t *testing.T) {
+	lru := NewLRUStore[string, int](3)
+	for _, k := range []string{"a", "b", "c"} {
+		require.NoError(t, lru.Put(k, len(k)))
+	}
+	lru.Get("a")
+
+	snapshot := bytes.Buffer{}
+	require.NoError(t, SnapshotStore[string, int](lru, &snapshot, GobCodec[item.Pair[string, int]]()))
+
+	restored := NewLRUStore[string, int](3)
+	require.NoError(t, restored.Put("z", 26))
+	require.NoError(t, RestoreStore[string, int](restored, &snapshot, GobCodec[item.Pair[string, int]]()))
+	// restoring replaces the previous entries, and keeps the recency of the snapshotted store
+	assert.Equal(t, OfSeq2(lru.All()).ToSlice(), OfSeq2(restored.All()).ToSlice())
+
+	// a snapshot can be restored into another kind of store
+	snapshot.Reset()
+	require.NoError(t, SnapshotStore[string, int](lru, &snapshot, GobCodec[item.Pair[string, int]]()))
+	mem := NewMemoryStore[string, int]()
+	require.NoError(t, RestoreStore[string, int](mem, &snapshot, GobCodec[item.Pair[string, int]]()))
+	assert.Equal(t, maps.Collect(lru.All()), maps.Collect(mem.All()))
+
+	assert.Error(t, RestoreStore[string, int](mem, bytes.NewBufferString("corrupt"), GobCodec[item.Pair[string, int]]()))
+}
@@ -3,9 +3,14 @@
 package stream
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"iter"
+	"slices"
+	"time"
 
+	"github.com/mariomac/gostream/clock"
 	"github.com/mariomac/gostream/order"
 )
 
@@ -18,17 +23,35 @@ import (
 // only performed when the terminal operation is initiated, and source elements are consumed only as
 // needed.
 type Stream[T any] interface {
-	// returns a new iterator to the stream
-	iterator() iterator[T]
+	// returns a new iterator to the stream, to be used within the provided execution
+	iterator(ex *execution) iterator[T]
 	// returns whether the stream is infinite or not
 	isInfinite() bool
+	// returns how the stages of the stream are executed (sequentially or in parallel)
+	properties() streamProps
 
 	// transformation operations
 
+	// ContinueOnError returns an equivalent stream whose fallible operations (e.g. MapErr,
+	// FilterErr or ForEachErr) do not stop the stream when they return an error. Instead,
+	// the failing items are discarded and the error-returning terminal operations return
+	// all the errors joined with errors.Join.
+	ContinueOnError() Stream[T]
+
+	// DropWhile returns a stream consisting of the remaining elements of this stream after
+	// discarding the longest prefix of elements that match the given predicate.
+	DropWhile(predicate func(T) bool) Stream[T]
+
 	// Filter returns a Stream consisting of the items of this stream that match the given
 	// predicate (this is, applying the predicate function over the item returns true).
 	Filter(predicate func(T) bool) Stream[T]
 
+	// FilterErr returns a Stream consisting of the items of this stream that match the given
+	// fallible predicate. If the predicate returns an error, the stream stops, and the error is
+	// returned by the error-returning terminal operations (e.g. ToSliceErr or ForEachErr),
+	// unless ContinueOnError has been invoked.
+	FilterErr(predicate func(T) (bool, error)) Stream[T]
+
 	// FlatMap returns a stream consisting of the results of replacing each element of this stream
 	// with the contents of a mapped stream produced by applying the provided mapping function to
 	// each element. Each mapped stream is closed after its contents have been placed into this
@@ -52,18 +75,84 @@ type Stream[T any] interface {
 	// to invoke the standalone function Map[IN,OUT](Stream[IN], func(IN)OUT) Stream[OUT].
 	Map(mapper func(T) T) Stream[T]
 
+	// OnClose returns an equivalent stream with an additional close handler. Close handlers
+	// are invoked when the Close method is invoked on the stream or any stream derived from it,
+	// or when a terminal operation finishes. Each close handler is invoked at most once, in the
+	// same order as they were registered.
+	OnClose(handler func()) Stream[T]
+
+	// Parallel returns an equivalent stream whose subsequent Map, Filter, FlatMap and Peek
+	// operations are distributed across the given number of worker goroutines. If workers
+	// is zero or negative, the value of runtime.GOMAXPROCS is used.
+	// Unless Unordered is invoked, the parallel operations keep the order of the elements.
+	// A panic in any of the functions of the parallel operations stops the workers, and is
+	// raised again in the goroutine that invokes the terminal operation.
+	Parallel(workers int) Stream[T]
+
 	// Peek peturns a stream consisting of the elements of this stream, additionally performing
 	// the provided action on each element as elements are consumed from the resulting stream.
 	Peek(consumer func(T)) Stream[T]
 
+	// Sequential returns an equivalent stream whose subsequent operations are executed
+	// sequentially in the goroutine that invokes the terminal operation.
+	Sequential() Stream[T]
+
 	// Skip returns a stream consisting of the remaining elements of this stream after discarding
 	// the first n elements of the stream.
 	Skip(n int) Stream[T]
 
 	// Sorted returns a stream consisting of the elements of this stream, sorted according
-	// to the provided order.Comparator.
+	// to the provided order.Comparator. The order of equal elements is not guaranteed: use
+	// SortedStable if they must keep their relative order.
+	// The elements are sorted incrementally, as the returned stream is pulled, so short-circuiting
+	// operations (e.g. FindFirst or Limit) do not need to pay for sorting the whole stream.
 	Sorted(comparator order.Comparator[T]) Stream[T]
 
+	// SortedStable returns a stream consisting of the elements of this stream, sorted according
+	// to the provided order.Comparator. Unlike Sorted, the elements that are equal according
+	// to the comparator keep their relative order.
+	SortedStable(comparator order.Comparator[T]) Stream[T]
+
+	// TakeUntil returns a stream consisting of the elements of this stream until the first
+	// element that matches the given predicate. If inclusive is true, the matching element
+	// is also part of the returned stream. The returned stream is considered finite, even if
+	// this stream is infinite, so terminal operations like ToSlice can be invoked on it.
+	TakeUntil(predicate func(T) bool, inclusive bool) Stream[T]
+
+	// TakeWhile returns a stream consisting of the longest prefix of elements of this stream
+	// that match the given predicate. The returned stream is considered finite, even if
+	// this stream is infinite, so terminal operations like ToSlice can be invoked on it.
+	TakeWhile(predicate func(T) bool) Stream[T]
+
+	// TopK returns a stream consisting of the k lowest elements of this stream according to
+	// the provided order.Comparator, sorted by that order. It returns the same elements as
+	// Sorted(comparator).Limit(k), but it only keeps k elements in memory.
+	TopK(comparator order.Comparator[T], k int) Stream[T]
+
+	// Unordered returns an equivalent stream whose parallel operations can emit their
+	// elements as soon as they are processed, without keeping the order of the input.
+	// It increases the throughput of parallel streams at the cost of a non-deterministic
+	// order of the elements. It has no effect on sequential streams.
+	Unordered() Stream[T]
+
+	// WithClock returns an equivalent stream whose time-based operations (e.g. BatchByTime or
+	// TumblingWindows) read the time from the provided clock.Clock, instead of the system clock.
+	WithClock(clk clock.Clock) Stream[T]
+
+	// WithContext returns an equivalent stream that is bound to the provided context.
+	// When the context is done, the stream stops pulling items from its source, even if it
+	// is blocked waiting for a channel, and the terminal operation finishes with the items
+	// that had been already processed. It replaces any context previously bound to the stream.
+	// To know whether a terminal operation finished because the context is done, use the
+	// context-aware versions of the terminal functions (e.g. ToSliceContext) or check
+	// the ctx.Err() method.
+	WithContext(ctx context.Context) Stream[T]
+
+	// WithEventTime returns an equivalent stream whose event-time operations (e.g. WindowJoin)
+	// read the event time of each element with the provided timestamp function, and track the
+	// progress of the event time with the watermarks of the provided strategy.
+	WithEventTime(timestamp func(T) time.Time, watermarks WatermarkStrategy) Stream[T]
+
 	// terminal operations
 
 	// AllMatch returns whether all elements of this stream match the provided predicate.
@@ -76,9 +165,22 @@ type Stream[T any] interface {
 	// the rest of the stream.
 	AnyMatch(predicate func(T) bool) bool
 
+	// Close the stream, invoking all the close handlers of this stream pipeline.
+	// Terminal operations automatically close the stream when they finish, so Close
+	// only needs to be explicitly invoked for streams that are not going to be
+	// processed by any terminal operation.
+	Close()
+
 	// Count of elements in this stream.
 	Count() int
 
+	// FindAny returns any element of this Stream along with true or, if the stream is
+	// empty, the zero value of the inner type along with false.
+	// In parallel streams, it returns the first element that any worker emits,
+	// regardless of its position in the stream, and stops the rest of the workers.
+	// In sequential streams, it behaves as FindFirst.
+	FindAny() (T, bool)
+
 	// FindFirst returns the first element of this Stream along with true or, if the
 	// stream is empty, the zero value of the inner type along with false.
 	FindFirst() (T, bool)
@@ -86,6 +188,12 @@ type Stream[T any] interface {
 	// ForEach invokes the consumer function for each item of the Stream.
 	ForEach(consumer func(T))
 
+	// ForEachErr invokes the fallible consumer function for each item of the Stream.
+	// It returns the first error returned by the consumer or any of the fallible operations
+	// of the stream. If the stream has been marked with ContinueOnError, the stream is
+	// processed until the end and all the errors are returned joined.
+	ForEachErr(consumer func(T) error) error
+
 	// Max returns the maximum element of this stream according to the provided Comparator,
 	// along with true if the stream is not empty. If the stream is empty, returns the zero
 	// value along with false.
@@ -110,6 +218,12 @@ type Stream[T any] interface {
 	// ToSlice returns a Slice Containing all the elements of this Stream.
 	ToSlice() []T
 
+	// ToSliceErr returns a Slice containing all the elements of this Stream, along with the
+	// first error returned by any of the fallible operations of the stream. If the stream
+	// has been marked with ContinueOnError, it returns all the successfully processed
+	// elements, and all the errors joined.
+	ToSliceErr() ([]T, error)
+
 	// Iter makes the Stream compatible with Go's "for ... range" syntax.
 	// The returned `iter.Seq2` has two fields: the first is the index of the item within
 	// the stream, and the second is the item itself.
@@ -136,23 +250,162 @@ func finishedIterator[T any]() (T, bool) {
 	return zeroVal, false
 }
 
-type iteratorSupplier[T any] func() iterator[T]
+type iteratorSupplier[T any] func(ex *execution) iterator[T]
+
+// execution holds the state that is shared by all the stages of a stream during
+// a single iteration, from the moment a terminal operation starts pulling items
+// until it finishes.
+type execution struct {
+	// ctx is cancelled when the terminal operation finishes, so the stages
+	// running in other goroutines know they must stop
+	ctx context.Context
+	// unordered is true when the terminal operation does not care about the
+	// order of the items, so parallel stages can emit them as soon as they are processed
+	unordered bool
+	// failures is shared by all the executions derived from the same terminal operation
+	failures *failures
+	// cleanups release the resources that the stages allocated for the execution
+	cleanups *cleanups
+}
+
+// ordered returns an execution that requires the upstream stages to keep the
+// order of the items. It is used by the stages whose result depends on the order
+// of their input (e.g. Limit or Skip).
+func (ex *execution) ordered() *execution {
+	if !ex.unordered {
+		return ex
+	}
+	o := *ex
+	o.unordered = false
+	return &o
+}
+
+// fork returns a copy of the execution that runs within the provided context, which must
+// be derived from the context of the original execution. The resources of the forked
+// execution must be released separately, by invoking its release method.
+func (ex *execution) fork(ctx context.Context) *execution {
+	f := *ex
+	f.ctx = ctx
+	f.cleanups = &cleanups{}
+	return &f
+}
+
+// cancelled returns true if the execution has been cancelled, so the stages must
+// stop pulling items from their input
+func (ex *execution) cancelled() bool {
+	select {
+	case <-ex.ctx.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// err returns the errors reported by the fallible operations of the execution, as well as
+// the reason why the execution was cancelled, if any.
+func (ex *execution) err() error {
+	errs := ex.failures.reported()
+	if len(errs) == 0 {
+		return context.Cause(ex.ctx)
+	}
+	return errors.Join(append(errs, context.Cause(ex.ctx))...)
+}
+
+// newExecution creates an execution that is cancelled when the returned function is
+// invoked or when any of the ctx argument or the context that is bound to the
+// stream (if any) are done. The returned function also releases the resources of the
+// execution and closes the stream.
+func newExecution(ctx context.Context, props streamProps) (*execution, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	stopBound := func() bool { return false }
+	if props.ctx != nil {
+		stopBound = context.AfterFunc(props.ctx, func() {
+			cancel(context.Cause(props.ctx))
+		})
+	}
+	ex := &execution{
+		ctx:      ctx,
+		failures: &failures{cancel: cancel, continueOnError: props.continueOnError},
+		cleanups: &cleanups{},
+	}
+	return ex, func() {
+		stopBound()
+		cancel(nil)
+		ex.release()
+		props.close()
+	}
+}
+
+// pull starts a new execution of the input stream and returns its iterator along with
+// a function that must be invoked to release the execution and close the stream once
+// the iteration finishes.
+func pull[T any](input Stream[T]) (iterator[T], func()) {
+	ex, stop := newExecution(context.Background(), input.properties())
+	return input.iterator(ex), stop
+}
+
+// streamProps defines how the stages of a stream are executed. They are inherited by
+// the streams derived from it.
+type streamProps struct {
+	// workers is the number of goroutines that process each parallel stage.
+	// Zero means that the stream is sequential.
+	workers int
+	// unordered parallel stages emit the items as soon as they are processed,
+	// instead of keeping the order of the input
+	unordered bool
+	// ctx, if not nil, cancels the executions of the stream when it is done
+	ctx context.Context
+	// continueOnError makes the fallible operations discard the failing items and
+	// keep processing the stream, instead of stopping it on the first error
+	continueOnError bool
+	// closers are invoked when the stream is closed
+	closers []*closeHandler
+	// clk, if not nil, replaces the system clock in the time-based operations
+	clk clock.Clock
+	// eventTime, if not nil, is the eventTime[T] of the elements of the stream
+	eventTime any
+}
+
+func (m streamProps) clock() clock.Clock {
+	if m.clk == nil {
+		return clock.System()
+	}
+	return m.clk
+}
+
+func (m streamProps) parallel() bool {
+	return m.workers > 0
+}
+
+// closingWith returns a copy of the properties that also closes the streams
+// with the other properties, when they are combined into a single stream.
+func (m streamProps) closingWith(others ...streamProps) streamProps {
+	for _, o := range others {
+		m.closers = append(slices.Clip(m.closers), o.closers...)
+	}
+	return m
+}
 
 // iterableStream is a generic stream iterated by the iterator returned by the
 // supplier function
 type iterableStream[T any] struct {
 	infinite bool
+	props    streamProps
 	supply   iteratorSupplier[T]
 }
 
-func (is *iterableStream[T]) iterator() iterator[T] {
-	return is.supply()
+func (is *iterableStream[T]) iterator(ex *execution) iterator[T] {
+	return is.supply(ex)
 }
 
 func (is *iterableStream[T]) isInfinite() bool {
 	return is.infinite
 }
 
+func (is *iterableStream[T]) properties() streamProps {
+	return is.props
+}
+
 func assertFinite[T any](is Stream[T]) {
 	if is.isInfinite() {
 		var v T
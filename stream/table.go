@@ -0,0 +1,242 @@
+package stream
+
+import (
+	"context"
+	"iter"
+	"maps"
+	"sync"
+
+	"github.com/mariomac/gostream/item"
+)
+
+// Table is a materialized view of a changelog stream, inspired by the KTable abstraction of
+// Kafka Streams: it keeps the latest value for each key of the changelog. Each element of the
+// changelog is an update that sets the value of its Key or, if its value is absent
+// (item.None), a tombstone that deletes the Key from the table.
+// The changelog is consumed in a background goroutine, so the Table is continuously updated
+// while it is read, e.g. to enrich a stream of events through JoinTable.
+// It is safe for concurrent use.
+type Table[K comparable, V any] struct {
+	mu       sync.RWMutex
+	entries  map[K]V
+	subs     map[*tableSubscription[K, V]]struct{}
+	finished bool
+	infinite bool
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewTable returns a Table that is materialized from the provided changelog stream.
+// The changelog starts being consumed in a background goroutine, until it ends or the
+// Close method is invoked. Then, the changelog stream is closed.
+func NewTable[K comparable, V any](changelog Stream[item.Pair[K, item.Optional[V]]]) *Table[K, V] {
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &Table[K, V]{
+		entries:  map[K]V{},
+		subs:     map[*tableSubscription[K, V]]struct{}{},
+		infinite: changelog.isInfinite(),
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	ex, stop := newExecution(ctx, changelog.properties())
+	next := changelog.iterator(ex.ordered())
+	go func() {
+		defer close(t.done)
+		defer t.finish()
+		defer stop()
+		for u, ok := next(); ok; u, ok = next() {
+			t.apply(u)
+		}
+	}()
+	return t
+}
+
+// Get returns the current value for the provided key, and whether the key is in the table.
+func (t *Table[K, V]) Get(key K) (V, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	v, ok := t.entries[key]
+	return v, ok
+}
+
+// Len returns the number of keys in the table.
+func (t *Table[K, V]) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.entries)
+}
+
+// Seq2 returns an iter.Seq2 over a snapshot of the key-value entries of the table, taken when
+// the iteration starts. The entries are iterated in no particular order.
+func (t *Table[K, V]) Seq2() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		t.mu.RLock()
+		snapshot := maps.Clone(t.entries)
+		t.mu.RUnlock()
+		for k, v := range snapshot {
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// Changes returns a stream with the updates that are applied to the table after its iteration
+// starts: a pair with the new value for each updated key, and a pair with an absent value for
+// each deleted key. Tombstones for keys that were not in the table are not emitted.
+// The stream ends when the changelog of the table ends, or the table is closed.
+// The updates are buffered until they are consumed, so a slow consumer never blocks the table.
+func (t *Table[K, V]) Changes() Stream[item.Pair[K, item.Optional[V]]] {
+	return &iterableStream[item.Pair[K, item.Optional[V]]]{
+		infinite: t.infinite,
+		supply: func(ex *execution) iterator[item.Pair[K, item.Optional[V]]] {
+			sub := t.subscribe()
+			ex.onRelease(func() {
+				t.unsubscribe(sub)
+			})
+			return func() (item.Pair[K, item.Optional[V]], bool) {
+				return sub.next(ex)
+			}
+		},
+	}
+}
+
+// Wait blocks until the changelog of the table has been entirely consumed, or the
+// table is closed.
+func (t *Table[K, V]) Wait() {
+	<-t.done
+}
+
+// Close stops consuming the changelog of the table, even if it is blocked waiting for
+// a channel, and waits for the changelog stream to be closed. The table keeps the entries
+// that were materialized until then.
+func (t *Table[K, V]) Close() {
+	t.cancel()
+	<-t.done
+}
+
+func (t *Table[K, V]) apply(u item.Pair[K, item.Optional[V]]) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if u.Val.Present {
+		t.entries[u.Key] = u.Val.Val
+	} else if _, ok := t.entries[u.Key]; ok {
+		delete(t.entries, u.Key)
+	} else {
+		return
+	}
+	for sub := range t.subs {
+		sub.push(u)
+	}
+}
+
+// finish marks the table as finished, ending all the subscriptions to its changes
+func (t *Table[K, V]) finish() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.finished = true
+	for sub := range t.subs {
+		sub.end()
+	}
+}
+
+func (t *Table[K, V]) subscribe() *tableSubscription[K, V] {
+	sub := &tableSubscription[K, V]{signal: make(chan struct{}, 1)}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.finished {
+		sub.end()
+	} else {
+		t.subs[sub] = struct{}{}
+	}
+	return sub
+}
+
+func (t *Table[K, V]) unsubscribe(sub *tableSubscription[K, V]) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.subs, sub)
+}
+
+// tableSubscription buffers the changes of a table until they are consumed
+type tableSubscription[K comparable, V any] struct {
+	mu      sync.Mutex
+	pending []item.Pair[K, item.Optional[V]]
+	ended   bool
+	// signal wakes up the consumer when there are pending changes, or the subscription ends
+	signal chan struct{}
+}
+
+func (s *tableSubscription[K, V]) push(u item.Pair[K, item.Optional[V]]) {
+	s.mu.Lock()
+	s.pending = append(s.pending, u)
+	s.mu.Unlock()
+	s.wakeUp()
+}
+
+func (s *tableSubscription[K, V]) end() {
+	s.mu.Lock()
+	s.ended = true
+	s.mu.Unlock()
+	s.wakeUp()
+}
+
+func (s *tableSubscription[K, V]) wakeUp() {
+	select {
+	case s.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (s *tableSubscription[K, V]) next(ex *execution) (item.Pair[K, item.Optional[V]], bool) {
+	for {
+		s.mu.Lock()
+		if len(s.pending) > 0 {
+			u := s.pending[0]
+			s.pending = s.pending[1:]
+			s.mu.Unlock()
+			return u, true
+		}
+		ended := s.ended
+		s.mu.Unlock()
+		if ended {
+			return finishedIterator[item.Pair[K, item.Optional[V]]]()
+		}
+		select {
+		case <-s.signal:
+		case <-ex.ctx.Done():
+			return finishedIterator[item.Pair[K, item.Optional[V]]]()
+		}
+	}
+}
+
+// JoinTable returns a stream with the Key, the value and the current value in the table for
+// the Key of each element of the events stream. The table is looked up when each event is
+// processed, so the events are enriched with the latest values of the table, even if its
+// changelog is still being consumed. The events whose Key is not in the table are discarded.
+func JoinTable[K comparable, E, V any](
+	events Stream[item.Pair[K, E]], table *Table[K, V],
+) Stream[item.Pair[K, item.Joined[E, V]]] {
+	// the table is looked up only once per event, and the events without a value are discarded
+	joined := LeftJoinTable(events, table).Filter(func(e item.Pair[K, item.Joined[E, item.Optional[V]]]) bool {
+		return e.Val.Right.Present
+	})
+	return Map(joined, func(e item.Pair[K, item.Joined[E, item.Optional[V]]]) item.Pair[K, item.Joined[E, V]] {
+		return item.Pair[K, item.Joined[E, V]]{Key: e.Key, Val: item.Joined[E, V]{Left: e.Val.Left, Right: e.Val.Right.Val}}
+	})
+}
+
+// LeftJoinTable returns a stream with the Key, the value and the current value in the table for
+// the Key of each element of the events stream, as JoinTable does. The events whose Key is not
+// in the table are emitted with an absent table value.
+func LeftJoinTable[K comparable, E, V any](
+	events Stream[item.Pair[K, E]], table *Table[K, V],
+) Stream[item.Pair[K, item.Joined[E, item.Optional[V]]]] {
+	return Map(events, func(e item.Pair[K, E]) item.Pair[K, item.Joined[E, item.Optional[V]]] {
+		v, found := table.Get(e.Key)
+		return item.Pair[K, item.Joined[E, item.Optional[V]]]{
+			Key: e.Key, Val: item.Joined[E, item.Optional[V]]{Left: e.Val, Right: item.Optional[V]{Val: v, Present: found}},
+		}
+	})
+}
@@ -0,0 +1,168 @@
+package stream
+
+import (
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mariomac/gostream/item"
+)
+
+func upsert[V any](key string, val V) item.Pair[string, item.Optional[V]] {
+	return item.Pair[string, item.Optional[V]]{Key: key, Val: item.Some(val)}
+}
+
+func tombstone[V any](key string) item.Pair[string, item.Optional[V]] {
+	return item.Pair[string, item.Optional[V]]{Key: key, Val: item.None[V]()}
+}
+
+// waitSubscribers waits until the table changes have the given number of subscribers
+func waitSubscribers[K comparable, V any](t *testing.T, table *Table[K, V], n int) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		table.mu.RLock()
+		defer table.mu.RUnlock()
+		return len(table.subs) == n
+	}, 5*time.Second, time.Millisecond)
+}
+
+func TestTable(t *testing.T) {
+	table := NewTable(Of(
+		upsert("apple", 3),
+		upsert("pear", 1),
+		upsert("apple", 5),
+		tombstone[int]("pear"),
+		tombstone[int]("plum"),
+		upsert("plum", 2),
+	))
+	table.Wait()
+	assert.Equal(t, 2, table.Len())
+	v, ok := table.Get("apple")
+	assert.True(t, ok)
+	assert.Equal(t, 5, v)
+	_, ok = table.Get("pear")
+	assert.False(t, ok)
+	assert.Equal(t, map[string]int{"apple": 5, "plum": 2}, maps.Collect(table.Seq2()))
+
+	// the table has finished, so its changes stream is empty
+	assert.Empty(t, table.Changes().ToSlice())
+}
+
+func TestTable_Changes(t *testing.T) {
+	changelog := make(chan item.Pair[string, item.Optional[int]])
+	closed := make(chan struct{})
+	table := NewTable(OfChannel(changelog).OnClose(func() { close(closed) }))
+	changes := make(chan []item.Pair[string, item.Optional[int]])
+	go func() {
+		changes <- table.Changes().ToSlice()
+	}()
+	waitSubscribers(t, table, 1)
+
+	changelog <- upsert("apple", 3)
+	changelog <- tombstone[int]("pear")
+	changelog <- upsert("pear", 1)
+	changelog <- upsert("apple", 5)
+	changelog <- tombstone[int]("pear")
+	close(changelog)
+	table.Wait()
+	<-closed
+
+	assert.Equal(t, []item.Pair[string, item.Optional[int]]{
+		upsert("apple", 3), upsert("pear", 1), upsert("apple", 5), tombstone[int]("pear"),
+	}, <-changes)
+}
+
+func TestTable_ChangesUnsubscribe(t *testing.T) {
+	changelog := make(chan item.Pair[string, item.Optional[int]])
+	table := NewTable(OfChannel(changelog))
+	defer table.Close()
+	firstChange := make(chan item.Pair[string, item.Optional[int]])
+	go func() {
+		for c := range table.Changes().Limit(1).Seq() {
+			firstChange <- c
+		}
+	}()
+	waitSubscribers(t, table, 1)
+	changelog <- upsert("apple", 3)
+	assert.Equal(t, upsert("apple", 3), <-firstChange)
+	waitSubscribers(t, table, 0)
+	// the table is not blocked after the subscriber leaves
+	changelog <- upsert("apple", 4)
+	changelog <- upsert("pear", 1)
+	require.Eventually(t, func() bool {
+		return table.Len() == 2
+	}, 5*time.Second, time.Millisecond)
+}
+
+func TestTable_Close(t *testing.T) {
+	changelog := make(chan item.Pair[string, item.Optional[int]])
+	closed := false
+	table := NewTable(OfChannel(changelog).OnClose(func() { closed = true }))
+	changelog <- upsert("apple", 3)
+	table.Close()
+	assert.True(t, closed)
+	v, ok := table.Get("apple")
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+	// the changelog is not consumed anymore
+	select {
+	case changelog <- upsert("apple", 4):
+		t.Fatal("changelog should not be consumed after closing the table")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestJoinTable(t *testing.T) {
+	changelog := make(chan item.Pair[string, item.Optional[int]])
+	table := NewTable(OfChannel(changelog))
+	defer table.Close()
+	applied := table.Changes()
+	updates, stop := pull(applied)
+	defer stop()
+	update := func(u item.Pair[string, item.Optional[int]]) {
+		changelog <- u
+		// waits for the update to be applied to the table
+		_, ok := updates()
+		require.True(t, ok)
+	}
+
+	events := make(chan kv[string])
+	inner := make(chan kv[item.Joined[string, int]])
+	go func() {
+		JoinTable(OfChannel(events), table).ForEach(func(e kv[item.Joined[string, int]]) {
+			inner <- e
+		})
+		close(inner)
+	}()
+
+	update(upsert("apple", 3))
+	events <- kv[string]{Key: "pear", Val: "bought"}
+	events <- kv[string]{Key: "apple", Val: "sold"}
+	assert.Equal(t, kv[item.Joined[string, int]]{Key: "apple", Val: item.Joined[string, int]{Left: "sold", Right: 3}},
+		<-inner)
+	update(upsert("pear", 1))
+	update(upsert("apple", 5))
+	events <- kv[string]{Key: "pear", Val: "sold"}
+	assert.Equal(t, kv[item.Joined[string, int]]{Key: "pear", Val: item.Joined[string, int]{Left: "sold", Right: 1}},
+		<-inner)
+	events <- kv[string]{Key: "apple", Val: "bought"}
+	assert.Equal(t, kv[item.Joined[string, int]]{Key: "apple", Val: item.Joined[string, int]{Left: "bought", Right: 5}},
+		<-inner)
+	update(tombstone[int]("apple"))
+	events <- kv[string]{Key: "apple", Val: "sold"}
+	close(events)
+	_, ok := <-inner
+	assert.False(t, ok)
+}
+
+func TestLeftJoinTable(t *testing.T) {
+	table := NewTable(Of(upsert("apple", 3)))
+	table.Wait()
+	assert.Equal(t, []kv[item.Joined[string, item.Optional[int]]]{
+		{Key: "apple", Val: item.Joined[string, item.Optional[int]]{Left: "sold", Right: item.Some(3)}},
+		{Key: "pear", Val: item.Joined[string, item.Optional[int]]{Left: "sold", Right: item.None[int]()}},
+	}, LeftJoinTable(Of(kv[string]{Key: "apple", Val: "sold"}, kv[string]{Key: "pear", Val: "sold"}), table).ToSlice())
+}
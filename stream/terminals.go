@@ -1,6 +1,7 @@
 package stream
 
 import (
+	"context"
 	"iter"
 	"slices"
 
@@ -15,7 +16,8 @@ func ForEach[T any](input Stream[T], consumer func(T)) {
 }
 
 func (is *iterableStream[T]) ForEach(consumer func(T)) {
-	next := is.iterator()
+	next, stop := pull[T](is)
+	defer stop()
 	for in, ok := next(); ok; in, ok = next() {
 		consumer(in)
 	}
@@ -29,8 +31,9 @@ func Iter[T any](input Stream[T]) func(func(int, T) bool) {
 }
 
 func (is *iterableStream[T]) Iter() iter.Seq2[int, T] {
-	next := is.iterator()
 	return func(yield func(int, T) bool) {
+		next, stop := pull[T](is)
+		defer stop()
 		idx := 0
 		for item, ok := next(); ok; item, ok = next() {
 			if !yield(idx, item) {
@@ -48,8 +51,9 @@ func Seq[T any](input Stream[T]) iter.Seq[T] {
 }
 
 func (is *iterableStream[T]) Seq() iter.Seq[T] {
-	next := is.iterator()
 	return func(yield func(T) bool) {
+		next, stop := pull[T](is)
+		defer stop()
 		for item, ok := next(); ok; item, ok = next() {
 			if !yield(item) {
 				return
@@ -63,7 +67,8 @@ func (is *iterableStream[T]) Seq() iter.Seq[T] {
 // explicitly created as streams of item.Pair[K, V])
 func Seq2[K comparable, V any](input Stream[item.Pair[K, V]]) iter.Seq2[K, V] {
 	return func(yield func(K, V) bool) {
-		next := input.iterator()
+		next, stop := pull(input)
+		defer stop()
 		for item, ok := next(); ok; item, ok = next() {
 			if !yield(item.Key, item.Val) {
 				return
@@ -105,7 +110,8 @@ func Reduce[T any](input Stream[T], accumulator func(a, b T) T) (T, bool) {
 
 func (is *iterableStream[T]) Reduce(accumulator func(a, b T) T) (T, bool) {
 	assertFinite[T](is)
-	next := is.iterator()
+	next, stop := pull[T](is)
+	defer stop()
 	accum, ok := next()
 	if !ok {
 		return accum, false
@@ -126,7 +132,8 @@ func AllMatch[T any](input Stream[T], predicate func(T) bool) bool {
 
 func (is *iterableStream[T]) AllMatch(predicate func(T) bool) bool {
 	assertFinite[T](is)
-	next := is.iterator()
+	next, stop := pull[T](is)
+	defer stop()
 	for r, ok := next(); ok; r, ok = next() {
 		if !predicate(r) {
 			return false
@@ -145,7 +152,8 @@ func AnyMatch[T any](input Stream[T], predicate func(T) bool) bool {
 
 func (is *iterableStream[T]) AnyMatch(predicate func(T) bool) bool {
 	assertFinite[T](is)
-	next := is.iterator()
+	next, stop := pull[T](is)
+	defer stop()
 	for r, ok := next(); ok; r, ok = next() {
 		if predicate(r) {
 			return true
@@ -175,7 +183,8 @@ func Count[T any](input Stream[T]) int {
 func (is *iterableStream[T]) Count() int {
 	assertFinite[T](is)
 	count := 0
-	next := is.iterator()
+	next, stop := pull[T](is)
+	defer stop()
 	for _, ok := next(); ok; _, ok = next() {
 		count++
 	}
@@ -190,7 +199,26 @@ func FindFirst[T any](input Stream[T]) (T, bool) {
 }
 
 func (is *iterableStream[T]) FindFirst() (T, bool) {
-	return is.iterator()()
+	next, stop := pull[T](is)
+	defer stop()
+	return next()
+}
+
+// FindAny returns any element of this Stream along with true or, if the stream is
+// empty, the zero value of the inner type along with false.
+// In parallel streams, it returns the first element that any worker emits,
+// regardless of its position in the stream, and stops the rest of the workers.
+// In sequential streams, it behaves as FindFirst.
+// This function is equivalent to invoking input.FindAny() as method.
+func FindAny[T any](input Stream[T]) (T, bool) {
+	return input.FindAny()
+}
+
+func (is *iterableStream[T]) FindAny() (T, bool) {
+	ex, stop := newExecution(context.Background(), is.props)
+	defer stop()
+	ex.unordered = true
+	return is.iterator(ex)()
 }
 
 // Max returns the maximum element of this stream according to the provided Comparator,
@@ -203,7 +231,8 @@ func Max[T any](input Stream[T], cmp order.Comparator[T]) (T, bool) {
 
 func (is *iterableStream[T]) Max(cmp order.Comparator[T]) (T, bool) {
 	assertFinite[T](is)
-	next := is.iterator()
+	next, stop := pull[T](is)
+	defer stop()
 	max, ok := next()
 	if !ok {
 		return max, false
@@ -226,7 +255,8 @@ func Min[T any](input Stream[T], cmp order.Comparator[T]) (T, bool) {
 
 func (is *iterableStream[T]) Min(cmp order.Comparator[T]) (T, bool) {
 	assertFinite[T](is)
-	next := is.iterator()
+	next, stop := pull[T](is)
+	defer stop()
 	min, ok := next()
 	if !ok {
 		return min, false
@@ -0,0 +1,256 @@
+package stream
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/mariomac/gostream/clock"
+)
+
+// WithClock returns an equivalent stream whose time-based operations read the time from
+// the provided clock.Clock, instead of the system clock. It is mainly intended for testing
+// the time-based operations with a clock.Manual.
+// This function is equivalent to invoking input.WithClock(clk) as method.
+func WithClock[T any](input Stream[T], clk clock.Clock) Stream[T] {
+	return input.WithClock(clk)
+}
+
+func (is *iterableStream[T]) WithClock(clk clock.Clock) Stream[T] {
+	props := is.props
+	props.clk = clk
+	return &iterableStream[T]{infinite: is.infinite, props: props, supply: is.supply}
+}
+
+// BatchByTime returns a stream whose elements are slices containing consecutive elements of
+// the input stream. A batch is emitted when it contains maxSize elements or when maxWait has
+// elapsed since its first element was received, whatever happens first. A non-positive maxSize
+// means that batches are only emitted by time.
+// When the input stream ends, the pending elements are emitted as a last, partial batch.
+// It is mostly useful for streams created from a channel, where elements arrive at
+// unpredictable times.
+// It panics if maxWait is not positive.
+func BatchByTime[T any](input Stream[T], maxSize int, maxWait time.Duration) Stream[[]T] {
+	if maxWait <= 0 {
+		panic(fmt.Sprintf("batch maxWait must be positive. Got: %v", maxWait))
+	}
+	return &iterableStream[[]T]{
+		infinite: input.isInfinite(),
+		props:    input.properties(),
+		supply: func(ex *execution) iterator[[]T] {
+			clk := input.properties().clock()
+			in := pullAsync(ex, input, clk)
+			var batch []T
+			var deadline time.Time
+			var timeout <-chan time.Time
+			// element that arrived after the deadline of the current batch, and
+			// starts the next batch
+			var pending *timestamped[T]
+			flush := func() ([]T, bool) {
+				b := batch
+				batch, timeout = nil, nil
+				return b, true
+			}
+			return func() ([]T, bool) {
+				for {
+					var t timestamped[T]
+					if pending != nil {
+						t, pending = *pending, nil
+					} else {
+						var ok bool
+						select {
+						case t, ok = <-in:
+							if !ok {
+								if len(batch) == 0 {
+									return nil, false
+								}
+								return flush()
+							}
+							t.repanic()
+						case <-timeout:
+							return flush()
+						case <-ex.ctx.Done():
+							return nil, false
+						}
+					}
+					if len(batch) > 0 && !t.ts.Before(deadline) {
+						pending = &t
+						return flush()
+					}
+					if len(batch) == 0 {
+						deadline = t.ts.Add(maxWait)
+						timeout = clk.After(deadline.Sub(clk.Now()))
+					}
+					batch = append(batch, t.val)
+					if maxSize > 0 && len(batch) >= maxSize {
+						return flush()
+					}
+				}
+			}
+		},
+	}
+}
+
+// TumblingWindows returns a stream of processing-time windows of the provided size. The windows
+// are adjacent and non-overlapping, and each window contains the elements that were received
+// between its start and its end. It is equivalent to HoppingWindows(input, size, size).
+// It panics if size is not positive.
+func TumblingWindows[T any](input Stream[T], size time.Duration) Stream[[]T] {
+	return HoppingWindows(input, size, size)
+}
+
+// HoppingWindows returns a stream of processing-time windows of the provided size, where each
+// window starts advance time after the start of the previous window. If advance is lower than
+// size, windows overlap and an element can belong to multiple windows.
+// Windows are aligned to multiples of advance since the zero time, and each window is emitted
+// as a slice with the elements that were received between its start and its end, as soon as its
+// end is reached. Windows without elements are not emitted.
+// When the input stream ends, the windows that are still open are emitted immediately.
+// It panics if size or advance are not positive.
+func HoppingWindows[T any](input Stream[T], size, advance time.Duration) Stream[[]T] {
+	if size <= 0 || advance <= 0 {
+		panic(fmt.Sprintf("window size and advance must be positive. Got: %v and %v", size, advance))
+	}
+	return &iterableStream[[]T]{
+		infinite: input.isInfinite(),
+		props:    input.properties(),
+		supply: func(ex *execution) iterator[[]T] {
+			clk := input.properties().clock()
+			in := pullAsync(ex, input, clk)
+			w := timeWindows[T]{size: size, advance: advance}
+			var timeout <-chan time.Time
+			// end of the window that the timeout has been armed for
+			var timeoutEnd time.Time
+			return func() ([]T, bool) {
+				for len(w.closed) == 0 {
+					select {
+					case t, ok := <-in:
+						if !ok {
+							if len(w.open) == 0 {
+								return nil, false
+							}
+							w.closeAll()
+							continue
+						}
+						t.repanic()
+						w.closeUntil(t.ts)
+						w.add(t)
+					case <-timeout:
+						timeout = nil
+						w.closeUntil(clk.Now())
+					case <-ex.ctx.Done():
+						return nil, false
+					}
+					if len(w.open) > 0 && (timeout == nil || !timeoutEnd.Equal(w.open[0].end)) {
+						timeoutEnd = w.open[0].end
+						timeout = clk.After(timeoutEnd.Sub(clk.Now()))
+					}
+				}
+				window := w.closed[0]
+				w.closed = w.closed[1:]
+				return window, true
+			}
+		},
+	}
+}
+
+// timeWindows keeps the open processing-time windows, sorted by start time, as well as the
+// closed windows that are pending to be emitted.
+type timeWindows[T any] struct {
+	size, advance time.Duration
+	open          []timeWindow[T]
+	closed        [][]T
+	// watermark is the time until which all the windows have been closed. Elements received
+	// before it (e.g. because they were waiting while a timer was processed) are considered
+	// to be received at the watermark, so they are never added to a closed window.
+	watermark time.Time
+}
+
+type timeWindow[T any] struct {
+	start, end time.Time
+	items      []T
+}
+
+func (w *timeWindows[T]) add(t timestamped[T]) {
+	ts := t.ts
+	if ts.Before(w.watermark) {
+		ts = w.watermark
+	}
+	for start := ts.Truncate(w.advance); start.Add(w.size).After(ts); start = start.Add(-w.advance) {
+		idx, found := slices.BinarySearchFunc(w.open, start, func(tw timeWindow[T], s time.Time) int {
+			return tw.start.Compare(s)
+		})
+		if !found {
+			w.open = slices.Insert(w.open, idx, timeWindow[T]{start: start, end: start.Add(w.size)})
+		}
+		w.open[idx].items = append(w.open[idx].items, t.val)
+	}
+}
+
+// closeUntil closes all the windows whose end is not after the provided time
+func (w *timeWindows[T]) closeUntil(now time.Time) {
+	if now.After(w.watermark) {
+		w.watermark = now
+	}
+	// windows with the same size and advance end in the same order as they start
+	for len(w.open) > 0 && !w.open[0].end.After(now) {
+		w.closed = append(w.closed, w.open[0].items)
+		w.open = w.open[1:]
+	}
+}
+
+func (w *timeWindows[T]) closeAll() {
+	for _, tw := range w.open {
+		w.closed = append(w.closed, tw.items)
+	}
+	w.open = nil
+}
+
+// timestamped is an element along with the time it was pulled from its stream
+type timestamped[T any] struct {
+	val T
+	ts  time.Time
+	// panicked is the value of a panic that was recovered while pulling the element
+	panicked any
+}
+
+// repanic raises again, in the goroutine that consumes the element, the panic that was
+// recovered while pulling it, if any
+func (t timestamped[T]) repanic() {
+	if t.panicked != nil {
+		panic(t.panicked)
+	}
+}
+
+// pullAsync pulls the elements of the input stream from a separate goroutine and forwards
+// them, timestamped with the provided clock, through the returned channel, which is closed
+// when the input stream ends. It allows the time-based operations to react to their timers
+// while the input stream is blocked.
+// The elements are timestamped before being forwarded, so the timestamp does not depend on
+// how long the element waits to be processed.
+// A panic in the input stream is recovered and forwarded as the last element of the channel,
+// and the consumer must raise it again (see timestamped.repanic).
+func pullAsync[T any](ex *execution, input Stream[T], clk clock.Clock) <-chan timestamped[T] {
+	out := make(chan timestamped[T])
+	upstream := ex.ordered().fork(ex.ctx)
+	go func() {
+		defer close(out)
+		defer upstream.release()
+		next := input.iterator(upstream)
+		for {
+			n, ok, panicked := pullRecovering(next)
+			if !ok && panicked == nil {
+				return
+			}
+			select {
+			case out <- timestamped[T]{val: n, ts: clk.Now(), panicked: panicked}:
+			case <-ex.ctx.Done():
+				return
+			}
+			if panicked != nil {
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,188 @@
+package stream
+
+import (
+	"iter"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mariomac/gostream/clock"
+	"github.com/mariomac/gostream/item"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// arrival of an element at a given offset since the epoch
+type arrival struct {
+	at  time.Duration
+	val int
+}
+
+// arrivals returns a sequence that sets the clock to the arrival time of each element
+// before yielding it, so the elements are timestamped deterministically
+func arrivals(clk *clock.Manual, as ...arrival) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for _, a := range as {
+			clk.Set(epoch.Add(a.at))
+			if !yield(a.val) {
+				return
+			}
+		}
+	}
+}
+
+// waitTimers waits until the clock has the provided number of armed timers
+func waitTimers(t *testing.T, clk *clock.Manual, timers int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for clk.Timers() != timers && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	require.Equal(t, timers, clk.Timers())
+}
+
+// panicsOn returns a mapper that panics with "boom" when it receives the provided element
+func panicsOn(p int) func(int) int {
+	return func(n int) int {
+		if n == p {
+			panic("boom")
+		}
+		return n
+	}
+}
+
+func TestBatchByTime_Size(t *testing.T) {
+	clk := clock.NewManual(epoch)
+	assert.Equal(t, [][]int{{1, 2}, {3, 4}, {5}},
+		BatchByTime(Of(1, 2, 3, 4, 5).WithClock(clk), 2, time.Minute).ToSlice())
+	assert.Empty(t, BatchByTime(Empty[int]().WithClock(clk), 2, time.Minute).ToSlice())
+	assert.Panics(t, func() {
+		BatchByTime(Of(1), 2, 0)
+	})
+}
+
+func TestBatchByTime_Wait(t *testing.T) {
+	clk := clock.NewManual(epoch)
+	batches := BatchByTime(OfSeq(arrivals(clk,
+		arrival{at: 0, val: 1},
+		arrival{at: time.Second, val: 2},
+		arrival{at: 3 * time.Second, val: 3},
+		arrival{at: 4 * time.Second, val: 4},
+		arrival{at: 5 * time.Second, val: 5},
+		arrival{at: 6 * time.Second, val: 6},
+	)).WithClock(clk), 3, 2*time.Second)
+	assert.Equal(t, [][]int{{1, 2}, {3, 4}, {5, 6}}, batches.ToSlice())
+}
+
+func TestBatchByTime_Timer(t *testing.T) {
+	clk := clock.NewManual(epoch)
+	src := make(chan int)
+	batches := make(chan []int)
+	go func() {
+		BatchByTime(OfChannel(src).WithClock(clk), 10, time.Second).ForEach(func(b []int) {
+			batches <- b
+		})
+		close(batches)
+	}()
+	src <- 1
+	waitTimers(t, clk, 1)
+	clk.Advance(time.Second)
+	// the batch is emitted by the timer, while the input is still blocked
+	assert.Equal(t, []int{1}, <-batches)
+
+	src <- 2
+	close(src)
+	assert.Equal(t, []int{2}, <-batches)
+	_, ok := <-batches
+	assert.False(t, ok)
+}
+
+func TestBatchByTime_InputPanic(t *testing.T) {
+	// the input is pulled from another goroutine, but the panic is raised in the caller
+	assert.PanicsWithValue(t, "boom", func() {
+		BatchByTime(Map(Of(1, 2, 3), panicsOn(2)), 10, time.Hour).ToSlice()
+	})
+}
+
+func TestTumblingWindows(t *testing.T) {
+	clk := clock.NewManual(epoch)
+	windows := TumblingWindows(OfSeq(arrivals(clk,
+		arrival{at: time.Second, val: 1},
+		arrival{at: 4 * time.Second, val: 2},
+		arrival{at: 12 * time.Second, val: 3},
+		arrival{at: 35 * time.Second, val: 4},
+		arrival{at: 39 * time.Second, val: 5},
+	)).WithClock(clk), 10*time.Second)
+	// empty windows are not emitted, and the open window is emitted when the input ends
+	assert.Equal(t, [][]int{{1, 2}, {3}, {4, 5}}, windows.ToSlice())
+	assert.Panics(t, func() {
+		TumblingWindows(Of(1), 0)
+	})
+}
+
+func TestHoppingWindows(t *testing.T) {
+	clk := clock.NewManual(epoch)
+	windows := HoppingWindows(OfSeq(arrivals(clk,
+		arrival{at: time.Second, val: 1},
+		arrival{at: 6 * time.Second, val: 2},
+		arrival{at: 12 * time.Second, val: 3},
+	)).WithClock(clk), 10*time.Second, 5*time.Second)
+	assert.Equal(t, [][]int{{1}, {1, 2}, {2, 3}, {3}}, windows.ToSlice())
+
+	// windows with gaps between them
+	windows = HoppingWindows(OfSeq(arrivals(clk,
+		arrival{at: time.Second, val: 1},
+		arrival{at: 6 * time.Second, val: 2},
+		arrival{at: 12 * time.Second, val: 3},
+	)).WithClock(clk), 5*time.Second, 10*time.Second)
+	assert.Equal(t, [][]int{{1}, {3}}, windows.ToSlice())
+}
+
+func TestTumblingWindows_Timer(t *testing.T) {
+	clk := clock.NewManual(epoch)
+	src := make(chan int)
+	windows := make(chan []int)
+	go func() {
+		TumblingWindows(OfChannel(src).WithClock(clk), 10*time.Second).ForEach(func(w []int) {
+			windows <- w
+		})
+		close(windows)
+	}()
+	src <- 1
+	waitTimers(t, clk, 1)
+	clk.Advance(10 * time.Second)
+	// the window is emitted by the timer, while the input is still blocked
+	assert.Equal(t, []int{1}, <-windows)
+
+	src <- 2
+	close(src)
+	assert.Equal(t, []int{2}, <-windows)
+	_, ok := <-windows
+	assert.False(t, ok)
+}
+
+func TestTumblingWindows_Infinite(t *testing.T) {
+	clk := clock.NewManual(epoch)
+	// an element per second
+	seconds := OfSeq(func(yield func(int) bool) {
+		for n := 0; ; n++ {
+			clk.Set(epoch.Add(time.Duration(n) * time.Second))
+			if !yield(n) {
+				return
+			}
+		}
+	})
+	windows := TumblingWindows(seconds.WithClock(clk), 3*time.Second)
+	assert.Equal(t, [][]int{{0, 1, 2}, {3, 4, 5}}, windows.Limit(2).ToSlice())
+	assert.True(t, TumblingWindows(Iterate(1, item.Increment[int]), time.Second).isInfinite())
+}
+
+func TestHoppingWindows_InputPanic(t *testing.T) {
+	assert.PanicsWithValue(t, "boom", func() {
+		TumblingWindows(Map(Of(1, 2, 3), panicsOn(2)), time.Hour).ToSlice()
+	})
+	assert.PanicsWithValue(t, "boom", func() {
+		HoppingWindows(Map(Of(1, 2, 3), panicsOn(2)), time.Hour, time.Minute).ToSlice()
+	})
+}
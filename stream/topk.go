@@ -0,0 +1,70 @@
+package stream
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/mariomac/gostream/order"
+)
+
+// TopK returns a stream consisting of the k lowest elements of the input stream according to
+// the provided order.Comparator, sorted by that order. It returns the same elements as
+// Sorted(comparator).Limit(k), but it only keeps k elements in memory, so it is suitable for
+// large streams, including streams created from a channel, that can't be buffered entirely.
+// Elements that are equal according to the comparator keep their relative order.
+// To get the k greatest elements, invert the comparator with order.Inverse.
+// This function is equivalent to invoking input.TopK(comparator, k) as method.
+func TopK[T any](input Stream[T], comparator order.Comparator[T], k int) Stream[T] {
+	return input.TopK(comparator, k)
+}
+
+func (is *iterableStream[T]) TopK(comparator order.Comparator[T], k int) Stream[T] {
+	assertFinite[T](is)
+	ranking := stably(comparator)
+	return &iterableStream[T]{
+		infinite: false,
+		props:    is.props,
+		supply: func(ex *execution) iterator[T] {
+			if k <= 0 {
+				return finishedIterator[T]
+			}
+			// the root of the heap is the greatest of the k lowest elements found so far,
+			// which is replaced whenever a lower element arrives
+			top := newPriorityQueue(order.Inverse(ranking))
+			next := is.iterator(ex.ordered())
+			seq := 0
+			for n, ok := next(); ok; n, ok = next() {
+				r := ranked[T]{val: n, seq: seq}
+				seq++
+				if top.len() < k {
+					top.push(r)
+				} else if ranking(r, top.peek()) < 0 {
+					top.replaceRoot(r)
+				}
+			}
+			slices.SortFunc(top.items, ranking)
+			items := make([]T, 0, len(top.items))
+			for _, r := range top.items {
+				items = append(items, r.val)
+			}
+			return sliceIterator(items)
+		},
+	}
+}
+
+// ranked is an element along with its position in the input stream
+type ranked[T any] struct {
+	val T
+	seq int
+}
+
+// stably returns a comparator of ranked elements that breaks the ties of the provided
+// comparator by their position, so equal elements keep their relative order
+func stably[T any](comparator order.Comparator[T]) order.Comparator[ranked[T]] {
+	return func(a, b ranked[T]) int {
+		if c := comparator(a.val, b.val); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.seq, b.seq)
+	}
+}
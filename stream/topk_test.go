@@ -0,0 +1,58 @@
+package stream
+
+import (
+	"cmp"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mariomac/gostream/item"
+	"github.com/mariomac/gostream/order"
+)
+
+func TestTopK(t *testing.T) {
+	in := Of(5, 3, 8, 1, 9, 2, 7)
+	assert.Equal(t, []int{1, 2, 3}, in.TopK(cmp.Compare[int], 3).ToSlice())
+	assert.Equal(t, []int{9, 8, 7}, TopK(in, order.Inverse(cmp.Compare[int]), 3).ToSlice())
+	assert.Equal(t, []int{1, 2, 3, 5, 7, 8, 9}, in.TopK(cmp.Compare[int], 10).ToSlice())
+	assert.Empty(t, in.TopK(cmp.Compare[int], 0).ToSlice())
+	assert.Empty(t, Empty[int]().TopK(cmp.Compare[int], 3).ToSlice())
+	assert.Panics(t, func() {
+		Iterate(1, item.Increment[int]).TopK(cmp.Compare[int], 3)
+	})
+}
+
+func TestTopK_EqualsSortedLimit(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	in := OfSlice(Generate(func() int { return rnd.IntN(50) }).Limit(1000).ToSlice())
+	for _, k := range []int{1, 5, 100, 999, 1000, 1001} {
+		assert.Equal(t, in.Sorted(cmp.Compare[int]).Limit(k).ToSlice(),
+			in.TopK(cmp.Compare[int], k).ToSlice())
+	}
+}
+
+func TestTopK_KeepsOrderOfEqualElements(t *testing.T) {
+	byKey := order.ByKey[int, string](cmp.Compare[int])
+	in := Of(
+		item.Pair[int, string]{Key: 2, Val: "a"},
+		item.Pair[int, string]{Key: 1, Val: "b"},
+		item.Pair[int, string]{Key: 2, Val: "c"},
+		item.Pair[int, string]{Key: 1, Val: "d"},
+		item.Pair[int, string]{Key: 2, Val: "e"},
+	)
+	assert.Equal(t, []item.Pair[int, string]{{Key: 1, Val: "b"}, {Key: 1, Val: "d"}, {Key: 2, Val: "a"}},
+		in.TopK(byKey, 3).ToSlice())
+}
+
+func TestTopK_Channel(t *testing.T) {
+	src := make(chan int)
+	go func() {
+		for i := 0; i < 10000; i++ {
+			src <- i
+		}
+		close(src)
+	}()
+	assert.Equal(t, []int{9999, 9998, 9997},
+		OfChannel(src).TopK(order.Inverse(cmp.Compare[int]), 3).ToSlice())
+}
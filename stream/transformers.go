@@ -1,8 +1,9 @@
 package stream
 
 import (
-	"slices"
+	"fmt"
 
+	"github.com/mariomac/gostream/item"
 	"github.com/mariomac/gostream/order"
 )
 
@@ -11,10 +12,16 @@ import (
 // When both the input and output type are the same, the operation can be
 // invoked as the method input.Map(mapper).
 func Map[IT, OT any](input Stream[IT], mapper func(IT) OT) Stream[OT] {
+	if input.properties().parallel() {
+		return parallelStage(input, input.isInfinite(), func(_ *execution, n IT) []OT {
+			return []OT{mapper(n)}
+		})
+	}
 	return &iterableStream[OT]{
 		infinite: input.isInfinite(),
-		supply: func() iterator[OT] {
-			next := input.iterator()
+		props:    input.properties(),
+		supply: func(ex *execution) iterator[OT] {
+			next := input.iterator(ex)
 			return func() (OT, bool) {
 				n, ok := next()
 				if !ok {
@@ -39,10 +46,19 @@ func Filter[T any](input Stream[T], predicate func(T) bool) Stream[T] {
 }
 
 func (is *iterableStream[T]) Filter(predicate func(T) bool) Stream[T] {
+	if is.props.parallel() {
+		return parallelStage[T, T](is, is.infinite, func(_ *execution, n T) []T {
+			if predicate(n) {
+				return []T{n}
+			}
+			return nil
+		})
+	}
 	return &iterableStream[T]{
 		infinite: is.infinite,
-		supply: func() iterator[T] {
-			next := is.iterator()
+		props:    is.props,
+		supply: func(ex *execution) iterator[T] {
+			next := is.iterator(ex)
 			return func() (T, bool) {
 				for {
 					n, ok := next()
@@ -69,8 +85,9 @@ func Limit[T any](input Stream[T], maxSize int) Stream[T] {
 func (is *iterableStream[T]) Limit(maxSize int) Stream[T] {
 	return &iterableStream[T]{
 		infinite: false,
-		supply: func() iterator[T] {
-			next := is.iterator()
+		props:    is.props,
+		supply: func(ex *execution) iterator[T] {
+			next := is.iterator(ex.ordered())
 			count := 0
 			return func() (T, bool) {
 				if count == maxSize {
@@ -93,8 +110,8 @@ func (is *iterableStream[T]) Limit(maxSize int) Stream[T] {
 // Distinct returns a stream consisting of the distinct elements (according to equality operator)
 // of the input stream.
 func Distinct[T comparable](input Stream[T]) Stream[T] {
-	return &iterableStream[T]{supply: func() iterator[T] {
-		next := input.iterator()
+	return &iterableStream[T]{props: input.properties(), supply: func(ex *execution) iterator[T] {
+		next := input.iterator(ex)
 		elems := map[T]struct{}{}
 		return func() (T, bool) {
 			for {
@@ -112,8 +129,44 @@ func Distinct[T comparable](input Stream[T]) Stream[T] {
 	}}
 }
 
+// DistinctWith returns a stream consisting of the distinct elements (according to equality
+// operator) of the input stream, keeping the already seen elements in the provided StateStore.
+// Unlike Distinct, the seen elements outlive the iteration of the stream, so a store that is
+// restored (e.g. with RestoreStore or OpenFileStore) keeps discarding the elements that were
+// seen by a previous run. A bounded store (e.g. NewLRUStore) can be used to limit the memory
+// usage, at the cost of emitting again the elements that have been evicted from it.
+// If the store returns an error, the stream stops, and the error is returned by the
+// error-returning terminal operations (e.g. ToSliceErr or ForEachErr).
+func DistinctWith[T comparable](input Stream[T], store StateStore[T, bool]) Stream[T] {
+	return &iterableStream[T]{props: input.properties(), supply: func(ex *execution) iterator[T] {
+		next := input.iterator(ex)
+		return func() (T, bool) {
+			for {
+				n, ok := next()
+				if !ok {
+					var zeroVal T
+					return zeroVal, false
+				}
+				if _, ok := store.Get(n); ok {
+					continue
+				}
+				if err := store.Put(n, true); err != nil {
+					if !ex.fail(fmt.Errorf("storing distinct element: %w", err)) {
+						return finishedIterator[T]()
+					}
+					continue
+				}
+				return n, true
+			}
+		}
+	}}
+}
+
 // Sorted returns a stream consisting of the elements of this stream, sorted according
-// to the provided order.Comparator.
+// to the provided order.Comparator. The order of equal elements is not guaranteed: use
+// SortedStable if they must keep their relative order.
+// The elements are sorted incrementally, as the returned stream is pulled, so short-circuiting
+// operations (e.g. FindFirst or Limit) do not need to pay for sorting the whole stream.
 // This function is equivalent to invoking input.Sorted(comparator) as method.
 func Sorted[T any](input Stream[T], comparator order.Comparator[T]) Stream[T] {
 	return input.Sorted(comparator)
@@ -123,21 +176,56 @@ func (is *iterableStream[T]) Sorted(comparator order.Comparator[T]) Stream[T] {
 	assertFinite[T](is)
 	return &iterableStream[T]{
 		infinite: false,
-		supply: func() iterator[T] {
-			items := is.ToSlice()
-			slices.SortFunc(items, comparator)
+		props:    is.props,
+		supply: func(ex *execution) iterator[T] {
+			var items []T
+			next := is.iterator(ex)
+			for n, ok := next(); ok; n, ok = next() {
+				items = append(items, n)
+			}
+			return heapIterator(heapify(items, comparator))
+		},
+	}
+}
+
+// SortedStable returns a stream consisting of the elements of this stream, sorted according
+// to the provided order.Comparator. Unlike Sorted, the elements that are equal according
+// to the comparator keep their relative order.
+// This function is equivalent to invoking input.SortedStable(comparator) as method.
+func SortedStable[T any](input Stream[T], comparator order.Comparator[T]) Stream[T] {
+	return input.SortedStable(comparator)
+}
+
+func (is *iterableStream[T]) SortedStable(comparator order.Comparator[T]) Stream[T] {
+	assertFinite[T](is)
+	return &iterableStream[T]{
+		infinite: false,
+		props:    is.props,
+		supply: func(ex *execution) iterator[T] {
+			var items []ranked[T]
+			next := is.iterator(ex.ordered())
+			for n, ok := next(); ok; n, ok = next() {
+				items = append(items, ranked[T]{val: n, seq: len(items)})
+			}
+			sorted := heapIterator(heapify(items, stably(comparator)))
 			return func() (T, bool) {
-				if len(items) == 0 {
-					return finishedIterator[T]()
-				}
-				n := items[0]
-				items = items[1:]
-				return n, true
+				r, ok := sorted()
+				return r.val, ok
 			}
 		},
 	}
 }
 
+// heapIterator pops the elements of the priority queue on demand
+func heapIterator[T any](pq *priorityQueue[T]) iterator[T] {
+	return func() (T, bool) {
+		if pq.len() == 0 {
+			return finishedIterator[T]()
+		}
+		return pq.pop(), true
+	}
+}
+
 // FlatMap returns a stream consisting of the results of replacing each element of this stream
 // with the contents of a mapped stream produced by applying the provided mapping function to
 // each element. Each mapped stream is closed after its contents have been placed into this
@@ -149,10 +237,29 @@ func (is *iterableStream[T]) Sorted(comparator order.Comparator[T]) Stream[T] {
 // When both the input and output type are the same, the operation can be
 // invoked as the method input.FlatMap(mapper).
 func FlatMap[IN, OUT any](input Stream[IN], mapper func(IN) Stream[OUT]) Stream[OUT] {
+	if input.properties().parallel() {
+		return parallelStage(input, false, func(ex *execution, n IN) []OUT {
+			outStream := mapper(n)
+			if outStream == nil {
+				return nil
+			}
+			var items []OUT
+			next, closeOutStream := iterateWithin(ex, outStream)
+			defer closeOutStream()
+			for outItem, ok := next(); ok; outItem, ok = next() {
+				items = append(items, outItem)
+			}
+			return items
+		})
+	}
 	return &iterableStream[OUT]{
-		supply: func() iterator[OUT] {
-			nextFromInputStream := input.iterator()
+		props: input.properties(),
+		supply: func(ex *execution) iterator[OUT] {
+			nextFromInputStream := input.iterator(ex)
 			var nextFromOutputStream iterator[OUT]
+			closeOutputStream := func() {}
+			// close the last mapped stream if the execution finishes before it is fully iterated
+			ex.onRelease(func() { closeOutputStream() })
 			return func() (OUT, bool) {
 				for {
 					for nextFromOutputStream == nil {
@@ -163,13 +270,15 @@ func FlatMap[IN, OUT any](input Stream[IN], mapper func(IN) Stream[OUT]) Stream[
 							return finishedIterator[OUT]()
 						}
 						if outStream := mapper(nextInputElem); outStream != nil {
-							nextFromOutputStream = outStream.iterator()
+							nextFromOutputStream, closeOutputStream = iterateWithin(ex, outStream)
 						}
 					}
 					if outItem, ok := nextFromOutputStream(); ok {
 						return outItem, true
 					} else {
 						// item's resulting outputStream has been iterated. Look for next input item
+						closeOutputStream()
+						closeOutputStream = func() {}
 						nextFromOutputStream = nil
 					}
 				}
@@ -190,10 +299,17 @@ func Peek[T any](input Stream[T], consumer func(T)) Stream[T] {
 }
 
 func (is *iterableStream[T]) Peek(consumer func(T)) Stream[T] {
+	if is.props.parallel() {
+		return parallelStage[T, T](is, is.infinite, func(_ *execution, n T) []T {
+			consumer(n)
+			return []T{n}
+		})
+	}
 	return &iterableStream[T]{
 		infinite: is.isInfinite(),
-		supply: func() iterator[T] {
-			next := is.iterator()
+		props:    is.props,
+		supply: func(ex *execution) iterator[T] {
+			next := is.iterator(ex)
 			return func() (T, bool) {
 				n, ok := next()
 				if !ok {
@@ -217,8 +333,9 @@ func Skip[T any](input Stream[T], n int) Stream[T] {
 func (is *iterableStream[T]) Skip(n int) Stream[T] {
 	return &iterableStream[T]{
 		infinite: is.isInfinite(),
-		supply: func() iterator[T] {
-			next := is.iterator()
+		props:    is.props,
+		supply: func(ex *execution) iterator[T] {
+			next := is.iterator(ex.ordered())
 			skipped := 0
 			return func() (T, bool) {
 				var it T
@@ -231,3 +348,121 @@ func (is *iterableStream[T]) Skip(n int) Stream[T] {
 		},
 	}
 }
+
+// Scan returns a stream consisting of the successive values of an accumulator, which starts
+// with the seed value and is updated with each element of the input stream by the provided
+// accumulator function. For example, scanning the stream (1, 2, 3) with a zero seed and a sum
+// accumulator results in the running totals (1, 3, 6).
+// Unlike Reduce, Scan is lazy, so it can be applied to infinite streams.
+func Scan[T, A any](input Stream[T], seed A, accumulator func(A, T) A) Stream[A] {
+	return &iterableStream[A]{
+		infinite: input.isInfinite(),
+		props:    input.properties(),
+		supply: func(ex *execution) iterator[A] {
+			next := input.iterator(ex.ordered())
+			acc := seed
+			return func() (A, bool) {
+				n, ok := next()
+				if !ok {
+					return finishedIterator[A]()
+				}
+				acc = accumulator(acc, n)
+				return acc, true
+			}
+		},
+	}
+}
+
+// ScanWith returns a stream consisting of the successive values of an accumulator, as Scan
+// does, keeping the accumulator in the provided StateStore, under the zero struct{} key.
+// Unlike Scan, the accumulator outlives the iteration of the stream, so a store that is
+// restored (e.g. with RestoreStore, OpenFileStore or CheckpointState) continues from the
+// accumulator of a previous run, instead of from the seed value.
+// If the store returns an error, the stream stops, and the error is returned by the
+// error-returning terminal operations (e.g. ToSliceErr or ForEachErr).
+func ScanWith[T, A any](input Stream[T], store StateStore[struct{}, A], seed A, accumulator func(A, T) A) Stream[A] {
+	keyed := Map(input, func(n T) item.Pair[struct{}, T] {
+		return item.Pair[struct{}, T]{Val: n}
+	})
+	return Map(ScanByKey(keyed, store, seed, accumulator), func(p item.Pair[struct{}, A]) A {
+		return p.Val
+	})
+}
+
+// TakeWhile returns a stream consisting of the longest prefix of elements of the input stream
+// that match the given predicate. The returned stream is considered finite, even if the input
+// stream is infinite, so terminal operations like ToSlice can be invoked on it.
+// This function is equivalent to invoking input.TakeWhile(predicate) as method.
+func TakeWhile[T any](input Stream[T], predicate func(T) bool) Stream[T] {
+	return input.TakeWhile(predicate)
+}
+
+func (is *iterableStream[T]) TakeWhile(predicate func(T) bool) Stream[T] {
+	return is.takeUntil(func(n T) bool { return !predicate(n) }, false)
+}
+
+// TakeUntil returns a stream consisting of the elements of the input stream until the first
+// element that matches the given predicate. If inclusive is true, the matching element
+// is also part of the returned stream. The returned stream is considered finite, even if the
+// input stream is infinite, so terminal operations like ToSlice can be invoked on it.
+// For example, to iterate until convergence:
+//
+//	stream.Iterate(x0, next).TakeUntil(converged, true).ToSlice()
+//
+// This function is equivalent to invoking input.TakeUntil(predicate, inclusive) as method.
+func TakeUntil[T any](input Stream[T], predicate func(T) bool, inclusive bool) Stream[T] {
+	return input.TakeUntil(predicate, inclusive)
+}
+
+func (is *iterableStream[T]) TakeUntil(predicate func(T) bool, inclusive bool) Stream[T] {
+	return is.takeUntil(predicate, inclusive)
+}
+
+func (is *iterableStream[T]) takeUntil(predicate func(T) bool, inclusive bool) Stream[T] {
+	return &iterableStream[T]{
+		infinite: false,
+		props:    is.props,
+		supply: func(ex *execution) iterator[T] {
+			next := is.iterator(ex.ordered())
+			return func() (T, bool) {
+				n, ok := next()
+				if !ok {
+					return finishedIterator[T]()
+				}
+				if predicate(n) {
+					next = finishedIterator[T]
+					if !inclusive {
+						return finishedIterator[T]()
+					}
+				}
+				return n, true
+			}
+		},
+	}
+}
+
+// DropWhile returns a stream consisting of the remaining elements of the input stream after
+// discarding the longest prefix of elements that match the given predicate.
+// This function is equivalent to invoking input.DropWhile(predicate) as method.
+func DropWhile[T any](input Stream[T], predicate func(T) bool) Stream[T] {
+	return input.DropWhile(predicate)
+}
+
+func (is *iterableStream[T]) DropWhile(predicate func(T) bool) Stream[T] {
+	return &iterableStream[T]{
+		infinite: is.infinite,
+		props:    is.props,
+		supply: func(ex *execution) iterator[T] {
+			next := is.iterator(ex.ordered())
+			dropping := true
+			return func() (T, bool) {
+				n, ok := next()
+				for dropping && ok && predicate(n) {
+					n, ok = next()
+				}
+				dropping = false
+				return n, ok
+			}
+		},
+	}
+}
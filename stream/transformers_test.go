@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/mariomac/gostream/item"
+	"github.com/mariomac/gostream/order"
 )
 
 func TestMap(t *testing.T) {
@@ -65,6 +66,28 @@ func TestDistinct(t *testing.T) {
 	)
 }
 
+func TestDistinctWith(t *testing.T) {
+	seen := NewMemoryStore[int, bool]()
+	assert.Equal(t, []int{1, 2, 3}, DistinctWith(Of(1, 1, 2, 3, 2), seen).ToSlice())
+	// the seen elements are kept after the stream iteration
+	assert.Equal(t, 3, seen.Len())
+	assert.Equal(t, []int{4}, DistinctWith(Of(3, 4, 1, 4), seen).ToSlice())
+
+	// a bounded store forgets the least recently seen elements
+	assert.Equal(t, []int{1, 2, 3, 1},
+		DistinctWith(Of(1, 2, 2, 3, 1), NewLRUStore[int, bool](2)).ToSlice())
+}
+
+func TestDistinctWith_StoreError(t *testing.T) {
+	out, err := DistinctWith(Of(1, 2, 3), newFailingStore[int, bool](2)).ToSliceErr()
+	assert.ErrorIs(t, err, errStoreFailed)
+	assert.Equal(t, []int{1}, out)
+
+	out, err = DistinctWith(Of(1, 2, 3).ContinueOnError(), newFailingStore[int, bool](2)).ToSliceErr()
+	assert.ErrorIs(t, err, errStoreFailed)
+	assert.Equal(t, []int{1, 3}, out)
+}
+
 func TestSort(t *testing.T) {
 	assert.Equal(t,
 		[]int{1, 1, 2, 3, 5, 6, 7, 8, 8},
@@ -72,6 +95,37 @@ func TestSort(t *testing.T) {
 			Sorted(cmp.Compare[int]).ToSlice())
 }
 
+func TestSort_Lazy(t *testing.T) {
+	const n = 10000
+	comparisons := 0
+	counting := func(a, b int) int {
+		comparisons++
+		return cmp.Compare(a, b)
+	}
+	in := Iterate(n, func(i int) int { return i - 1 }).Limit(n)
+	first, ok := in.Sorted(counting).FindFirst()
+	require.True(t, ok)
+	assert.Equal(t, 1, first)
+	// heapifying the stream requires O(n) comparisons, instead of O(n log n)
+	assert.Less(t, comparisons, 3*n)
+
+	comparisons = 0
+	assert.Equal(t, []int{1, 2, 3}, in.Sorted(counting).Limit(3).ToSlice())
+	assert.Less(t, comparisons, 3*n)
+}
+
+func TestSortedStable(t *testing.T) {
+	words := Of("dddd", "bb", "a", "cc", "eeee", "f", "ggg")
+	byLength := func(a, b string) int {
+		return cmp.Compare(len(a), len(b))
+	}
+	assert.Equal(t, []string{"a", "f", "bb", "cc", "ggg", "dddd", "eeee"},
+		words.SortedStable(byLength).ToSlice())
+	assert.Equal(t, []string{"dddd", "eeee", "ggg", "bb", "cc", "a", "f"},
+		SortedStable(words, order.Inverse(byLength)).ToSlice())
+	assert.Empty(t, Empty[string]().SortedStable(byLength).ToSlice())
+}
+
 func TestFlapMap(t *testing.T) {
 	generateCharSequence := func(in string) Stream[byte] {
 		return OfSlice([]byte(in))
@@ -129,3 +183,75 @@ func TestSkip(t *testing.T) {
 	assert.Empty(t, Of(1, 2, 3).Skip(3).ToSlice())
 	assert.Equal(t, []int{4, 5, 6}, Of(1, 2, 3, 4, 5, 6).Skip(3).ToSlice())
 }
+
+func TestScan(t *testing.T) {
+	assert.Equal(t, []int{1, 3, 6, 10}, Scan(Of(1, 2, 3, 4), 0, item.Add[int]).ToSlice())
+	assert.Empty(t, Scan(Empty[int](), 0, item.Add[int]).ToSlice())
+
+	maxes := Scan(Of(3, 1, 4, 1, 5, 9, 2), 0, func(acc, n int) int {
+		return max(acc, n)
+	})
+	assert.Equal(t, []int{3, 3, 4, 4, 5, 9, 9}, maxes.ToSlice())
+
+	// with a different accumulator type
+	digits := Scan(Of(3, 1, 4), "", func(acc string, n int) string {
+		return acc + strconv.Itoa(n)
+	})
+	assert.Equal(t, []string{"3", "31", "314"}, digits.ToSlice())
+}
+
+func TestScanWith(t *testing.T) {
+	total := NewMemoryStore[struct{}, int]()
+	assert.Equal(t, []int{1, 3, 6}, ScanWith(Of(1, 2, 3), total, 0, item.Add[int]).ToSlice())
+	// the accumulator continues from the state of the store
+	assert.Equal(t, []int{10, 15}, ScanWith(Of(4, 5), total, 0, item.Add[int]).ToSlice())
+
+	out, err := ScanWith(Of(1, 2), newFailingStore[struct{}, int](struct{}{}), 0, item.Add[int]).ToSliceErr()
+	assert.ErrorIs(t, err, errStoreFailed)
+	assert.Empty(t, out)
+}
+
+func TestScan_Infinite(t *testing.T) {
+	balances := Scan(Iterate(1, item.Increment[int]), 100, func(balance, n int) int {
+		return balance - n
+	})
+	require.True(t, balances.isInfinite())
+	assert.Equal(t, []int{99, 97, 94, 90}, balances.Limit(4).ToSlice())
+}
+
+func TestTakeWhile(t *testing.T) {
+	assert.Equal(t, []int{1, 2, 3}, Of(1, 2, 3, 4, 1, 2).TakeWhile(item.LessThan(4)).ToSlice())
+	assert.Empty(t, Of(5, 1, 2).TakeWhile(item.LessThan(4)).ToSlice())
+	assert.Empty(t, TakeWhile(Empty[int](), item.LessThan(4)).ToSlice())
+
+	// turns an infinite stream into a finite one
+	powers := Iterate(1, func(n int) int { return n * 2 }).TakeWhile(item.LessThan(100))
+	require.False(t, powers.isInfinite())
+	assert.Equal(t, []int{1, 2, 4, 8, 16, 32, 64}, powers.ToSlice())
+	assert.Equal(t, 7, powers.Count())
+}
+
+func TestTakeUntil(t *testing.T) {
+	in := Of(1, 2, 3, 4, 1, 2)
+	assert.Equal(t, []int{1, 2, 3}, in.TakeUntil(item.GreaterThan(3), false).ToSlice())
+	assert.Equal(t, []int{1, 2, 3, 4}, in.TakeUntil(item.GreaterThan(3), true).ToSlice())
+	assert.Equal(t, []int{1, 2, 3, 4, 1, 2}, TakeUntil(in, item.GreaterThan(10), true).ToSlice())
+
+	// iterate until convergence: halving until the value is lower than one
+	halves := Iterate(1000.0, func(n float64) float64 { return n / 2 }).
+		TakeUntil(func(n float64) bool { return n < 1 }, true)
+	require.False(t, halves.isInfinite())
+	assert.Equal(t, 11, halves.Count())
+	assert.Equal(t, []float64{0.9765625, 1.953125, 3.90625},
+		halves.Sorted(cmp.Compare[float64]).Limit(3).ToSlice())
+}
+
+func TestDropWhile(t *testing.T) {
+	assert.Equal(t, []int{4, 1, 2}, Of(1, 2, 3, 4, 1, 2).DropWhile(item.LessThan(4)).ToSlice())
+	assert.Empty(t, Of(1, 2).DropWhile(item.LessThan(4)).ToSlice())
+	assert.Equal(t, []int{5, 1}, DropWhile(Of(5, 1), item.LessThan(4)).ToSlice())
+
+	naturals := Iterate(1, item.Increment[int]).DropWhile(item.LessThan(10))
+	require.True(t, naturals.isInfinite())
+	assert.Equal(t, []int{10, 11, 12}, naturals.Limit(3).ToSlice())
+}
@@ -0,0 +1,118 @@
+package stream
+
+import (
+	"fmt"
+
+	"github.com/mariomac/gostream/item"
+)
+
+// Chunk returns a stream whose elements are slices containing each consecutive group of
+// size elements of the input stream. The last slice might contain less than size elements
+// if the length of the input stream is not a multiple of size.
+// Chunks are created lazily: in infinite streams or streams created from a channel, a chunk
+// is emitted as soon as it has been filled.
+// It panics if size is not positive.
+func Chunk[T any](input Stream[T], size int) Stream[[]T] {
+	if size <= 0 {
+		panic(fmt.Sprintf("chunk size must be positive. Got: %d", size))
+	}
+	return &iterableStream[[]T]{
+		infinite: input.isInfinite(),
+		props:    input.properties(),
+		supply: func(ex *execution) iterator[[]T] {
+			next := input.iterator(ex.ordered())
+			return func() ([]T, bool) {
+				var chunk []T
+				for len(chunk) < size {
+					n, ok := next()
+					if !ok {
+						return chunk, len(chunk) > 0
+					}
+					chunk = append(chunk, n)
+				}
+				return chunk, true
+			}
+		},
+	}
+}
+
+// Window returns a stream of sliding windows over the input stream. Each window is a slice
+// containing size consecutive elements, and each window starts step elements after the start
+// of the previous window:
+//   - if step is lower than size, windows overlap (sliding windows)
+//   - if step is equal to size, windows are adjacent (tumbling windows, equivalent to Chunk
+//     except that a trailing partial chunk is dropped)
+//   - if step is greater than size, some elements between windows are discarded (hopping windows)
+//
+// Only complete windows are emitted, so the last elements of a finite stream are discarded if
+// they are not enough to fill a window.
+// Windows are created lazily: in infinite streams or streams created from a channel, a window
+// is emitted as soon as it has been filled.
+// It panics if size or step are not positive.
+func Window[T any](input Stream[T], size, step int) Stream[[]T] {
+	if size <= 0 || step <= 0 {
+		panic(fmt.Sprintf("window size and step must be positive. Got: %d and %d", size, step))
+	}
+	return &iterableStream[[]T]{
+		infinite: input.isInfinite(),
+		props:    input.properties(),
+		supply: func(ex *execution) iterator[[]T] {
+			next := input.iterator(ex.ordered())
+			var buffer []T
+			// elements to discard before starting the next window, when step > size
+			toSkip := 0
+			return func() ([]T, bool) {
+				for ; toSkip > 0; toSkip-- {
+					if _, ok := next(); !ok {
+						return nil, false
+					}
+				}
+				for len(buffer) < size {
+					n, ok := next()
+					if !ok {
+						return nil, false
+					}
+					buffer = append(buffer, n)
+				}
+				window := make([]T, size)
+				copy(window, buffer)
+				if step < size {
+					buffer = append(buffer[:0], buffer[step:]...)
+				} else {
+					buffer = buffer[:0]
+					toSkip = step - size
+				}
+				return window, true
+			}
+		},
+	}
+}
+
+// Pairwise returns a stream of item.Pair elements, each containing two consecutive elements
+// of the input stream: the Key is the previous element and the Val is the next element.
+// For example, the stream (1, 2, 3, 4) would result in the pairs (1, 2), (2, 3) and (3, 4).
+// Streams with less than two elements result in an empty stream.
+func Pairwise[T comparable](input Stream[T]) Stream[item.Pair[T, T]] {
+	return &iterableStream[item.Pair[T, T]]{
+		infinite: input.isInfinite(),
+		props:    input.properties(),
+		supply: func(ex *execution) iterator[item.Pair[T, T]] {
+			next := input.iterator(ex.ordered())
+			prev, started := finishedIterator[T]()
+			return func() (item.Pair[T, T], bool) {
+				if !started {
+					if prev, started = next(); !started {
+						return finishedIterator[item.Pair[T, T]]()
+					}
+				}
+				n, ok := next()
+				if !ok {
+					return finishedIterator[item.Pair[T, T]]()
+				}
+				pair := item.Pair[T, T]{Key: prev, Val: n}
+				prev = n
+				return pair, true
+			}
+		},
+	}
+}
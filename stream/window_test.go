@@ -0,0 +1,83 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mariomac/gostream/item"
+)
+
+func TestChunk(t *testing.T) {
+	assert.Equal(t, [][]int{{1, 2, 3}, {4, 5, 6}, {7}},
+		Chunk(Of(1, 2, 3, 4, 5, 6, 7), 3).ToSlice())
+	assert.Equal(t, [][]int{{1, 2}, {3, 4}},
+		Chunk(Of(1, 2, 3, 4), 2).ToSlice())
+	assert.Empty(t, Chunk(Empty[int](), 2).ToSlice())
+	assert.Panics(t, func() {
+		Chunk(Of(1, 2), 0)
+	})
+}
+
+func TestChunk_Infinite(t *testing.T) {
+	chunks := Chunk(Iterate(1, item.Increment[int]), 2)
+	assert.True(t, chunks.isInfinite())
+	assert.Equal(t, [][]int{{1, 2}, {3, 4}, {5, 6}}, chunks.Limit(3).ToSlice())
+}
+
+func TestChunk_Channel(t *testing.T) {
+	src := make(chan int)
+	chunks := make(chan []int)
+	go Chunk(OfChannel(src), 2).ForEach(func(c []int) {
+		chunks <- c
+	})
+	src <- 1
+	src <- 2
+	// the chunk is emitted as soon as it is filled, without waiting for more elements
+	assert.Equal(t, []int{1, 2}, <-chunks)
+	src <- 3
+	close(src)
+	assert.Equal(t, []int{3}, <-chunks)
+}
+
+func TestWindow(t *testing.T) {
+	in := Of(1, 2, 3, 4, 5, 6, 7)
+	// sliding
+	assert.Equal(t, [][]int{{1, 2, 3}, {2, 3, 4}, {3, 4, 5}, {4, 5, 6}, {5, 6, 7}},
+		Window(in, 3, 1).ToSlice())
+	assert.Equal(t, [][]int{{1, 2, 3}, {3, 4, 5}, {5, 6, 7}},
+		Window(in, 3, 2).ToSlice())
+	// tumbling
+	assert.Equal(t, [][]int{{1, 2, 3}, {4, 5, 6}},
+		Window(in, 3, 3).ToSlice())
+	// hopping
+	assert.Equal(t, [][]int{{1, 2}, {5, 6}},
+		Window(in, 2, 4).ToSlice())
+	assert.Empty(t, Window(Of(1, 2), 3, 1).ToSlice())
+	assert.Panics(t, func() {
+		Window(in, 3, 0)
+	})
+}
+
+func TestWindow_Infinite(t *testing.T) {
+	windows := Window(Iterate(1, item.Increment[int]), 2, 1)
+	assert.True(t, windows.isInfinite())
+	// check that emitted windows are not modified after being emitted
+	assert.Equal(t, [][]int{{1, 2}, {2, 3}, {3, 4}}, windows.Limit(3).ToSlice())
+}
+
+func TestPairwise(t *testing.T) {
+	assert.Equal(t, []item.Pair[int, int]{{Key: 1, Val: 2}, {Key: 2, Val: 3}, {Key: 3, Val: 4}},
+		Pairwise(Of(1, 2, 3, 4)).ToSlice())
+	assert.Empty(t, Pairwise(Of(1)).ToSlice())
+	assert.Empty(t, Pairwise(Empty[int]()).ToSlice())
+
+	// differences between consecutive elements of an infinite stream
+	squares := Iterate(1, item.Increment[int]).Map(func(n int) int { return n * n })
+	diffs := Map(Pairwise(squares), func(p item.Pair[int, int]) int {
+		return p.Val - p.Key
+	})
+	require.True(t, diffs.isInfinite())
+	assert.Equal(t, []int{3, 5, 7, 9}, diffs.Limit(4).ToSlice())
+}
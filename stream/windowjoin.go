@@ -0,0 +1,171 @@
+package stream
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/mariomac/gostream/item"
+)
+
+// WindowJoin returns a stream with the Key, the left value and the right value of each pair of
+// elements from the left and right streams whose Key is equal and whose event times are at
+// most within apart. Both streams must have an event time attached with WithEventTime.
+// Since the elements of each stream are joined as soon as they arrive, the order of the joined
+// elements depends on how the arrivals of both streams interleave, but which elements are
+// joined does not: it only depends on the event time of the elements and the watermark
+// strategy of each stream. Then, it is useful to join streams created with OfChannel, as well
+// as to replay historical data with OfSlice.
+// Each stream keeps the elements that can still be joined with the next elements of the other
+// stream, and evicts them when the watermark of the other stream passes their event time plus
+// within. The elements whose event time is before the watermark of their stream are late: they
+// are not joined, but emitted through the returned late stream, with an absent value in the
+// side of the join that they don't belong to.
+// Both returned streams share a single iteration of the input streams, in the same way as
+// the streams returned by UnzipStreams. If the late elements are not needed, the late stream
+// must be closed before iterating the joined stream.
+// It panics if within is negative or if any of the input streams has no event time.
+func WindowJoin[K comparable, L, R any](
+	left Stream[item.Pair[K, L]], right Stream[item.Pair[K, R]], within time.Duration,
+) (
+	joined Stream[item.Pair[K, item.Joined[L, R]]],
+	late Stream[item.Pair[K, item.Joined[item.Optional[L], item.Optional[R]]]],
+) {
+	if within < 0 {
+		panic(fmt.Sprintf("join interval can't be negative. Got: %v", within))
+	}
+	return splitSide(windowJoin(left, right, within))
+}
+
+// windowJoinOutput is either a joined element or a late element of a WindowJoin
+type windowJoinOutput[K comparable, L, R any] = withSide[item.Pair[K, item.Joined[L, R]], outerJoined[K, L, R]]
+
+func windowJoin[K comparable, L, R any](
+	left Stream[item.Pair[K, L]], right Stream[item.Pair[K, R]], within time.Duration,
+) Stream[windowJoinOutput[K, L, R]] {
+	leftTime, rightTime := eventTimeOf(left), eventTimeOf(right)
+	props := left.properties().closingWith(right.properties())
+	return &iterableStream[windowJoinOutput[K, L, R]]{
+		infinite: left.isInfinite() || right.isInfinite(),
+		props:    props,
+		supply: func(ex *execution) iterator[windowJoinOutput[K, L, R]] {
+			ls := newJoinSide(pullAsync(ex, left, props.clock()), leftTime)
+			rs := newJoinSide(pullAsync(ex, right, props.clock()), rightTime)
+			var pending []windowJoinOutput[K, L, R]
+			return func() (windowJoinOutput[K, L, R], bool) {
+				for len(pending) == 0 {
+					if ls.in == nil && rs.in == nil {
+						return finishedIterator[windowJoinOutput[K, L, R]]()
+					}
+					select {
+					case l, ok := <-ls.in:
+						if !ok {
+							endJoinSide(ls, rs)
+							continue
+						}
+						l.repanic()
+						matches, isLate := joinArrival(ls, rs, l.val, within)
+						if isLate {
+							pending = append(pending, windowJoinOutput[K, L, R]{
+								side: joinOf(l.val.Key, item.Some(l.val.Val), item.None[R]()), isSide: true,
+							})
+						}
+						for _, r := range matches {
+							pending = append(pending, windowJoinOutput[K, L, R]{main: innerJoined(joinOf(l.val.Key, item.Some(l.val.Val), item.Some(r)))})
+						}
+					case r, ok := <-rs.in:
+						if !ok {
+							endJoinSide(rs, ls)
+							continue
+						}
+						r.repanic()
+						matches, isLate := joinArrival(rs, ls, r.val, within)
+						if isLate {
+							pending = append(pending, windowJoinOutput[K, L, R]{
+								side: joinOf(r.val.Key, item.None[L](), item.Some(r.val.Val)), isSide: true,
+							})
+						}
+						for _, l := range matches {
+							pending = append(pending, windowJoinOutput[K, L, R]{main: innerJoined(joinOf(r.val.Key, item.Some(l), item.Some(r.val.Val)))})
+						}
+					case <-ex.ctx.Done():
+						return finishedIterator[windowJoinOutput[K, L, R]]()
+					}
+				}
+				o := pending[0]
+				pending = pending[1:]
+				return o, true
+			}
+		},
+	}
+}
+
+// joinSide keeps the state of one of the input streams of a WindowJoin
+type joinSide[K comparable, V any] struct {
+	in      <-chan timestamped[item.Pair[K, V]]
+	tracker *eventTracker[item.Pair[K, V]]
+	// stored elements, by key, that can be joined with the next elements of the other side
+	stored map[K][]timestamped[V]
+	// expiry sorts the keys of the stored elements by event time, to evict them
+	expiry *priorityQueue[timestamped[K]]
+}
+
+func newJoinSide[K comparable, V any](
+	in <-chan timestamped[item.Pair[K, V]], et eventTime[item.Pair[K, V]],
+) *joinSide[K, V] {
+	return &joinSide[K, V]{
+		in:      in,
+		tracker: newEventTracker(et),
+		stored:  map[K][]timestamped[V]{},
+		expiry: newPriorityQueue(func(a, b timestamped[K]) int {
+			return a.ts.Compare(b.ts)
+		}),
+	}
+}
+
+// joinArrival processes an element that arrived to the own side of a WindowJoin, returning
+// the values of the other side that are joined to it, or whether it is late.
+func joinArrival[K comparable, A, B any](
+	own *joinSide[K, A], other *joinSide[K, B], n item.Pair[K, A], within time.Duration,
+) (matches []B, late bool) {
+	ts, late := own.tracker.observe(n)
+	if late {
+		return nil, true
+	}
+	for _, o := range other.stored[n.Key] {
+		if d := o.ts.Sub(ts); d >= -within && d <= within {
+			matches = append(matches, o.val)
+		}
+	}
+	// the element is only stored if the other side can still provide elements to join with it
+	if !ts.Add(within).Before(other.tracker.watermark) {
+		own.stored[n.Key] = append(own.stored[n.Key], timestamped[A]{val: n.Val, ts: ts})
+		own.expiry.push(timestamped[K]{val: n.Key, ts: ts})
+	}
+	other.evictBefore(own.tracker.watermark.Add(-within))
+	return matches, false
+}
+
+// evictBefore removes the stored elements whose event time is before the provided time
+func (s *joinSide[K, V]) evictBefore(t time.Time) {
+	for s.expiry.len() > 0 && s.expiry.peek().ts.Before(t) {
+		key := s.expiry.pop().val
+		kept := slices.DeleteFunc(s.stored[key], func(e timestamped[V]) bool {
+			return e.ts.Before(t)
+		})
+		if len(kept) == 0 {
+			delete(s.stored, key)
+		} else {
+			s.stored[key] = kept
+		}
+	}
+}
+
+// endJoinSide marks the own side as ended, so the stored elements of the other side can't
+// be joined anymore
+func endJoinSide[K comparable, A, B any](own *joinSide[K, A], other *joinSide[K, B]) {
+	own.in = nil
+	own.tracker.end()
+	clear(other.stored)
+	other.expiry = newPriorityQueue(other.expiry.comparator)
+}
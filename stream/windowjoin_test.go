@@ -0,0 +1,172 @@
+package stream
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mariomac/gostream/item"
+)
+
+func joinedEvents(key, left, right string) kv[item.Joined[string, string]] {
+	return kv[item.Joined[string, string]]{Key: key, Val: item.Joined[string, string]{Left: left, Right: right}}
+}
+
+// impressions and clicks of ads, by ad name
+var (
+	impressions = []kv[event]{
+		{Key: "a", Val: event{sec: 1, name: "imp1"}},
+		{Key: "b", Val: event{sec: 2, name: "imp2"}},
+		{Key: "a", Val: event{sec: 10, name: "imp3"}},
+		// late: the watermark is already at 8
+		{Key: "a", Val: event{sec: 4, name: "imp4"}},
+		{Key: "b", Val: event{sec: 9, name: "imp5"}},
+	}
+	clicks = []kv[event]{
+		{Key: "a", Val: event{sec: 3, name: "click1"}},
+		{Key: "b", Val: event{sec: 8, name: "click2"}},
+		{Key: "b", Val: event{sec: 7, name: "click3"}},
+		{Key: "a", Val: event{sec: 12, name: "click4"}},
+		// late: the watermark is already at 10
+		{Key: "a", Val: event{sec: 2, name: "click5"}},
+	}
+	expectedClickJoins = []kv[item.Joined[string, string]]{
+		joinedEvents("a", "imp1", "click1"),
+		joinedEvents("a", "imp3", "click4"),
+		joinedEvents("b", "imp5", "click2"),
+		joinedEvents("b", "imp5", "click3"),
+	}
+	expectedLateClicks = []outerJoined[string, string, string]{
+		joinOf("a", item.Some("imp4"), item.None[string]()),
+		joinOf("a", item.None[string](), item.Some("click5")),
+	}
+)
+
+func joinClicks(imps, clks Stream[kv[event]]) (Stream[kv[item.Joined[string, string]]], Stream[outerJoined[string, string, string]]) {
+	joined, late := WindowJoin(
+		imps.WithEventTime(eventTimeOfPair, BoundedOutOfOrderness(2*time.Second)),
+		clks.WithEventTime(eventTimeOfPair, BoundedOutOfOrderness(2*time.Second)),
+		4*time.Second)
+	return Map(joined, func(j kv[item.Joined[event, event]]) kv[item.Joined[string, string]] {
+			return joinedEvents(j.Key, j.Val.Left.name, j.Val.Right.name)
+		}), Map(late, func(l outerJoined[string, event, event]) outerJoined[string, string, string] {
+			return joinOf(l.Key,
+				item.Optional[string]{Val: l.Val.Left.Val.name, Present: l.Val.Left.Present},
+				item.Optional[string]{Val: l.Val.Right.Val.name, Present: l.Val.Right.Present})
+		})
+}
+
+func TestWindowJoin(t *testing.T) {
+	joined, late := joinClicks(OfSlice(impressions), OfSlice(clicks))
+	var lateEvents []outerJoined[string, string, string]
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		lateEvents = late.ToSlice()
+	}()
+	assert.ElementsMatch(t, expectedClickJoins, joined.ToSlice())
+	wg.Wait()
+	assert.ElementsMatch(t, expectedLateClicks, lateEvents)
+}
+
+func TestWindowJoin_Channels(t *testing.T) {
+	imps, clks := make(chan kv[event]), make(chan kv[event])
+	closed := 0
+	joined, late := joinClicks(
+		OfChannel(imps).OnClose(func() { closed++ }),
+		OfChannel(clks).OnClose(func() { closed++ }))
+	joins, lates := make(chan kv[item.Joined[string, string]]), make(chan outerJoined[string, string, string])
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		joined.ForEach(func(j kv[item.Joined[string, string]]) { joins <- j })
+		close(joins)
+	}()
+	go func() {
+		defer wg.Done()
+		late.ForEach(func(l outerJoined[string, string, string]) { lates <- l })
+		close(lates)
+	}()
+
+	imps <- impressions[0]
+	clks <- clicks[0]
+	assert.Equal(t, expectedClickJoins[0], <-joins)
+	imps <- impressions[1]
+	imps <- impressions[2]
+	clks <- clicks[1]
+	clks <- clicks[2]
+	clks <- clicks[3]
+	assert.Equal(t, expectedClickJoins[1], <-joins)
+	imps <- impressions[3]
+	assert.Equal(t, expectedLateClicks[0], <-lates)
+	imps <- impressions[4]
+	assert.Equal(t, expectedClickJoins[2], <-joins)
+	assert.Equal(t, expectedClickJoins[3], <-joins)
+	clks <- clicks[4]
+	assert.Equal(t, expectedLateClicks[1], <-lates)
+	close(imps)
+	close(clks)
+	_, ok := <-joins
+	assert.False(t, ok)
+	_, ok = <-lates
+	assert.False(t, ok)
+	wg.Wait()
+	assert.Equal(t, 2, closed)
+}
+
+func TestWindowJoin_DiscardLate(t *testing.T) {
+	joined, late := joinClicks(OfSlice(impressions), OfSlice(clicks))
+	late.Close()
+	assert.ElementsMatch(t, expectedClickJoins, joined.ToSlice())
+}
+
+func TestWindowJoin_InputPanic(t *testing.T) {
+	imps := Map(OfSlice(impressions), func(i kv[event]) kv[event] {
+		if i.Val.name == "imp3" {
+			panic("boom")
+		}
+		return i
+	})
+	joined, late := joinClicks(imps, OfSlice(clicks))
+	late.Close()
+	assert.PanicsWithValue(t, "boom", func() {
+		joined.ToSlice()
+	})
+}
+
+func TestWindowJoin_Eviction(t *testing.T) {
+	eventTime := eventTimeOf(OfSlice(impressions).WithEventTime(eventTimeOfPair, AscendingTimestamps()))
+	imps, clks := newJoinSide(nil, eventTime), newJoinSide(nil, eventTime)
+	arrive := func(own, other *joinSide[string, event], sec int) {
+		joinArrival(own, other, kv[event]{Key: "a", Val: event{sec: sec}}, 2*time.Second)
+	}
+	arrive(imps, clks, 1)
+	arrive(imps, clks, 2)
+	arrive(imps, clks, 5)
+	assert.Len(t, imps.stored["a"], 3)
+	// the click watermark is 4, so the impressions before 2 can't be joined anymore
+	arrive(clks, imps, 4)
+	assert.Len(t, imps.stored["a"], 2)
+	// the impression watermark is 5, so the click is kept until the impression
+	// watermark passes 6
+	assert.Len(t, clks.stored["a"], 1)
+	arrive(imps, clks, 7)
+	assert.Empty(t, clks.stored)
+	// the clicks that can't be joined with any future impression are not stored
+	arrive(clks, imps, 4)
+	assert.Empty(t, clks.stored)
+}
+
+func TestWindowJoin_Panics(t *testing.T) {
+	withTime := OfSlice(impressions).WithEventTime(eventTimeOfPair, AscendingTimestamps())
+	assert.Panics(t, func() {
+		WindowJoin(withTime, OfSlice(clicks), time.Second)
+	})
+	assert.Panics(t, func() {
+		WindowJoin(withTime, withTime, -time.Second)
+	})
+}
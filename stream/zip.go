@@ -0,0 +1,268 @@
+package stream
+
+import (
+	"sync"
+
+	"github.com/mariomac/gostream/item"
+)
+
+// Zip returns a stream of item.Pair elements, where the Key of each pair is taken from the
+// a stream and the Val is taken from the b stream, in the same order. The resulting stream
+// ends when any of the input streams ends, so it is only infinite if both input streams
+// are infinite.
+func Zip[A comparable, B any](a Stream[A], b Stream[B]) Stream[item.Pair[A, B]] {
+	return ZipWith(a, b, func(ka A, vb B) item.Pair[A, B] {
+		return item.Pair[A, B]{Key: ka, Val: vb}
+	})
+}
+
+// ZipWith returns a stream whose elements are the result of applying the zipper function
+// to each pair of elements from the a and b streams, in the same order. The resulting stream
+// ends when any of the input streams ends, so it is only infinite if both input streams
+// are infinite.
+func ZipWith[A, B, C any](a Stream[A], b Stream[B], zipper func(A, B) C) Stream[C] {
+	return &iterableStream[C]{
+		infinite: a.isInfinite() && b.isInfinite(),
+		props:    a.properties().closingWith(b.properties()),
+		supply: func(ex *execution) iterator[C] {
+			ord := ex.ordered()
+			nextA, nextB := a.iterator(ord), b.iterator(ord)
+			return func() (C, bool) {
+				na, ok := nextA()
+				if !ok {
+					return finishedIterator[C]()
+				}
+				nb, ok := nextB()
+				if !ok {
+					return finishedIterator[C]()
+				}
+				return zipper(na, nb), true
+			}
+		},
+	}
+}
+
+// ZipLongest returns a stream of item.Pair elements, where the Key of each pair is taken from
+// the a stream and the Val is taken from the b stream, in the same order. Unlike Zip, the
+// resulting stream ends when both input streams end: the missing elements of the shortest
+// stream are replaced by the defA or defB default values. The resulting stream is infinite
+// if any of the input streams is infinite.
+func ZipLongest[A comparable, B any](a Stream[A], b Stream[B], defA A, defB B) Stream[item.Pair[A, B]] {
+	return &iterableStream[item.Pair[A, B]]{
+		infinite: a.isInfinite() || b.isInfinite(),
+		props:    a.properties().closingWith(b.properties()),
+		supply: func(ex *execution) iterator[item.Pair[A, B]] {
+			ord := ex.ordered()
+			nextA, nextB := a.iterator(ord), b.iterator(ord)
+			return func() (item.Pair[A, B], bool) {
+				na, okA := nextA()
+				if !okA {
+					na, nextA = defA, finishedIterator[A]
+				}
+				nb, okB := nextB()
+				if !okB {
+					nb, nextB = defB, finishedIterator[B]
+				}
+				if !okA && !okB {
+					return finishedIterator[item.Pair[A, B]]()
+				}
+				return item.Pair[A, B]{Key: na, Val: nb}, true
+			}
+		},
+	}
+}
+
+// Unzip returns two slices containing, respectively, the Key and the Val of each
+// item.Pair element from the input stream. It is the reverse operation of Zip.
+// This function panics if the input stream is infinite.
+func Unzip[A comparable, B any](input Stream[item.Pair[A, B]]) ([]A, []B) {
+	assertFinite(input)
+	var as []A
+	var bs []B
+	input.ForEach(func(p item.Pair[A, B]) {
+		as = append(as, p.Key)
+		bs = append(bs, p.Val)
+	})
+	return as, bs
+}
+
+// UnzipStreams returns two streams containing, respectively, the Key and the Val of each
+// item.Pair element from the input stream. It is the lazy counterpart of Unzip.
+// Both streams share a single iteration of the input stream: the elements that are pulled
+// by one of the streams are buffered until the other stream consumes them, so consuming
+// only one of the streams requires buffering the whole input stream for the other.
+// The returned streams can be safely consumed from different goroutines, and the input
+// stream is closed when both of them have finished their iteration. Each stream can only
+// be iterated once until the other stream has finished its iteration too.
+// A stream that is not going to be iterated can be discarded by invoking its Close method
+// before the other stream is iterated, so its elements are not buffered. If both streams
+// are discarded, the input stream is closed without being iterated.
+func UnzipStreams[A comparable, B any](input Stream[item.Pair[A, B]]) (Stream[A], Stream[B]) {
+	u := &unzipper[item.Pair[A, B]]{input: input}
+	return unzipStream(u, &u.a, &u.b, func(p item.Pair[A, B]) (A, bool) { return p.Key, true }),
+		unzipStream(u, &u.b, &u.a, func(p item.Pair[A, B]) (B, bool) { return p.Val, true })
+}
+
+// unzipStream returns a stream with the elements of the unzipper input that are accepted
+// by the get function of a side, mapped by it.
+// Closing the stream before iterating it discards the side, so the elements that are pulled
+// by the other side are not buffered for it.
+func unzipStream[P, T any](
+	u *unzipper[P], own, other *unzipBuffer[P], get func(P) (T, bool),
+) Stream[T] {
+	own.accepts = func(p P) bool {
+		_, ok := get(p)
+		return ok
+	}
+	props := u.input.properties()
+	// the input stream is closed when both streams finish their iteration
+	props.closers = []*closeHandler{{handler: func() {
+		u.discard(own, other)
+	}}}
+	return &iterableStream[T]{
+		infinite: u.input.isInfinite(),
+		props:    props,
+		supply: func(ex *execution) iterator[T] {
+			return unzipSide(u, ex, own, other, get)
+		},
+	}
+}
+
+// unzipper shares an iteration of the input stream between both sides of UnzipStreams
+type unzipper[P any] struct {
+	input Stream[P]
+	mu    sync.Mutex
+	// next and stop are set while any of the sides is iterating the input
+	next  iterator[P]
+	stop  func()
+	ended bool
+	// pulling is true while a side is pulling the next element from the input. The mutex
+	// is not held while pulling, so the other side can still consume its pending elements
+	pulling bool
+	// pulled is closed and replaced after each pull, to wake up the side that waits for it
+	pulled chan struct{}
+	a, b   unzipBuffer[P]
+}
+
+// unzipBuffer keeps the elements that have been pulled from the input but not yet
+// consumed by a side of the unzipper
+type unzipBuffer[P any] struct {
+	pending []P
+	// done is true when the side has finished its iteration, so it does not need to
+	// buffer more elements
+	done bool
+	// started is true when the side has been iterated at least once
+	started bool
+	// discarded is true when the side has been closed without being iterated
+	discarded bool
+	// accepts returns whether an element is delivered to the side
+	accepts func(P) bool
+}
+
+// wants returns whether an element that has been pulled by the other side must be buffered
+func (b *unzipBuffer[P]) wants(p P) bool {
+	return !b.done && !b.discarded && b.accepts(p)
+}
+
+// finishing returns whether the input can be stopped when the other side finishes
+func (b *unzipBuffer[P]) finishing() bool {
+	return b.done || b.discarded
+}
+
+// release stops the shared iteration of the input
+func (u *unzipper[P]) release() {
+	if u.stop != nil {
+		u.stop()
+	}
+	u.next, u.stop = nil, nil
+	u.a.done, u.a.pending = false, nil
+	u.b.done, u.b.pending = false, nil
+}
+
+func (u *unzipper[P]) discard(own, other *unzipBuffer[P]) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if own.started {
+		return
+	}
+	own.discarded, own.pending = true, nil
+	if other.done {
+		u.release()
+	} else if other.discarded {
+		// none of the sides is going to iterate the input, so it is closed without pulling it
+		u.input.properties().close()
+	}
+}
+
+func unzipSide[P, T any](
+	u *unzipper[P], ex *execution, own, other *unzipBuffer[P], get func(P) (T, bool),
+) iterator[T] {
+	u.mu.Lock()
+	own.started, own.discarded = true, false
+	if u.next == nil {
+		u.next, u.stop = pull(u.input)
+		u.ended = false
+		u.pulled = make(chan struct{})
+	}
+	u.mu.Unlock()
+	ex.onRelease(func() {
+		u.mu.Lock()
+		defer u.mu.Unlock()
+		own.done, own.pending = true, nil
+		if other.finishing() {
+			u.release()
+		}
+	})
+	return func() (T, bool) {
+		u.mu.Lock()
+		defer u.mu.Unlock()
+		for {
+			if own.done || ex.cancelled() {
+				return finishedIterator[T]()
+			}
+			if len(own.pending) > 0 {
+				p := own.pending[0]
+				own.pending = own.pending[1:]
+				t, _ := get(p)
+				return t, true
+			}
+			if u.ended {
+				return finishedIterator[T]()
+			}
+			if u.pulling {
+				// waits for the other side to pull the next element
+				pulled := u.pulled
+				u.mu.Unlock()
+				select {
+				case <-pulled:
+				case <-ex.ctx.Done():
+				}
+				u.mu.Lock()
+				continue
+			}
+			u.pulling = true
+			u.mu.Unlock()
+			p, ok, panicked := pullRecovering(u.next)
+			u.mu.Lock()
+			u.pulling = false
+			close(u.pulled)
+			u.pulled = make(chan struct{})
+			if panicked != nil {
+				// the panic is raised with the mutex locked, so it is properly unlocked,
+				// and the other side finishes instead of waiting for the input
+				u.ended = true
+				panic(panicked)
+			}
+			if !ok {
+				u.ended = true
+				return finishedIterator[T]()
+			}
+			if other.wants(p) {
+				other.pending = append(other.pending, p)
+			}
+			if t, ok := get(p); ok {
+				return t, true
+			}
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package stream
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mariomac/gostream/item"
+)
+
+func TestZip(t *testing.T) {
+	zipped := Zip(Of("a", "b", "c"), Of(1, 2, 3, 4))
+	assert.False(t, zipped.isInfinite())
+	assert.Equal(t, []item.Pair[string, int]{{Key: "a", Val: 1}, {Key: "b", Val: 2}, {Key: "c", Val: 3}},
+		zipped.ToSlice())
+	assert.Empty(t, Zip(Empty[string](), Of(1, 2)).ToSlice())
+
+	// one finite input makes the result finite
+	zipped = Zip(Of("a", "b"), Iterate(1, item.Increment[int]))
+	assert.False(t, zipped.isInfinite())
+	assert.Equal(t, []item.Pair[string, int]{{Key: "a", Val: 1}, {Key: "b", Val: 2}}, zipped.ToSlice())
+}
+
+func TestZipWith(t *testing.T) {
+	naturals := Iterate(1, item.Increment[int])
+	squares := naturals.Map(func(n int) int { return n * n })
+	sums := ZipWith(naturals, squares, item.Add[int])
+	require.True(t, sums.isInfinite())
+	assert.Equal(t, []int{2, 6, 12, 20}, sums.Limit(4).ToSlice())
+
+	assert.Equal(t, []string{"a1", "b2"},
+		ZipWith(Of("a", "b", "c"), Of(1, 2), func(s string, n int) string {
+			return s + strconv.Itoa(n)
+		}).ToSlice())
+}
+
+func TestZipLongest(t *testing.T) {
+	assert.Equal(t, []item.Pair[string, int]{{Key: "a", Val: 1}, {Key: "b", Val: 2}, {Key: "-", Val: 3}},
+		ZipLongest(Of("a", "b"), Of(1, 2, 3), "-", 0).ToSlice())
+	assert.Equal(t, []item.Pair[string, int]{{Key: "a", Val: 1}, {Key: "b", Val: 0}},
+		ZipLongest(Of("a", "b"), Of(1), "-", 0).ToSlice())
+	assert.Empty(t, ZipLongest(Empty[string](), Empty[int](), "-", 0).ToSlice())
+
+	zipped := ZipLongest(Of("a"), Iterate(1, item.Increment[int]), "-", 0)
+	require.True(t, zipped.isInfinite())
+	assert.Equal(t, []item.Pair[string, int]{{Key: "a", Val: 1}, {Key: "-", Val: 2}}, zipped.Limit(2).ToSlice())
+}
+
+func TestUnzip(t *testing.T) {
+	letters, numbers := Unzip(Zip(Of("a", "b", "c"), Of(1, 2, 3)))
+	assert.Equal(t, []string{"a", "b", "c"}, letters)
+	assert.Equal(t, []int{1, 2, 3}, numbers)
+
+	letters, numbers = Unzip(Empty[item.Pair[string, int]]())
+	assert.Empty(t, letters)
+	assert.Empty(t, numbers)
+}
+
+func TestUnzipStreams(t *testing.T) {
+	pulled := 0
+	input := Zip(Of("a", "b", "c"), Of(1, 2, 3)).Peek(func(item.Pair[string, int]) {
+		pulled++
+	})
+	letters, numbers := UnzipStreams(input)
+	assert.Equal(t, []string{"a", "b", "c"}, letters.ToSlice())
+	// the numbers were buffered while iterating the letters
+	assert.Equal(t, []int{1, 2, 3}, numbers.ToSlice())
+	assert.Equal(t, 3, pulled)
+
+	// both streams can be iterated again, once both finished their previous iteration
+	assert.Equal(t, []int{2, 4, 6}, numbers.Map(func(n int) int { return n * 2 }).ToSlice())
+	assert.Equal(t, []string{"a", "b", "c"}, letters.ToSlice())
+	assert.Equal(t, 6, pulled)
+}
+
+func TestUnzipStreams_Lazy(t *testing.T) {
+	closed := 0
+	input := Map(Iterate(1, item.Increment[int]), func(n int) item.Pair[int, string] {
+		return item.Pair[int, string]{Key: n, Val: strconv.Itoa(n)}
+	}).OnClose(func() { closed++ })
+	numbers, strs := UnzipStreams(input)
+	require.True(t, numbers.isInfinite())
+	require.True(t, strs.isInfinite())
+
+	assert.Equal(t, []int{1, 2}, numbers.Limit(2).ToSlice())
+	assert.Zero(t, closed)
+	assert.Equal(t, []string{"1", "2", "3"}, strs.Limit(3).ToSlice())
+	// the input is closed once both streams finished
+	assert.Equal(t, 1, closed)
+}
+
+func TestUnzipStreams_Concurrent(t *testing.T) {
+	src := make(chan item.Pair[int, int])
+	evens, odds := UnzipStreams(OfChannel(src))
+	var wg sync.WaitGroup
+	var evensSum, oddsSum int
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		evensSum, _ = evens.Reduce(item.Add[int])
+	}()
+	go func() {
+		defer wg.Done()
+		oddsSum, _ = odds.Reduce(item.Add[int])
+	}()
+	for i := 0; i < 100; i += 2 {
+		src <- item.Pair[int, int]{Key: i, Val: i + 1}
+	}
+	close(src)
+	wg.Wait()
+	assert.Equal(t, 2450, evensSum)
+	assert.Equal(t, 2500, oddsSum)
+}
+
+func TestUnzipStreams_Discard(t *testing.T) {
+	closed := false
+	letters, numbers := UnzipStreams(Zip(Of("a", "b", "c"), Of(1, 2, 3)).OnClose(func() { closed = true }))
+	numbers.Close()
+	assert.Equal(t, []string{"a", "b", "c"}, letters.ToSlice())
+	// the input has been closed without waiting for the discarded stream
+	assert.True(t, closed)
+}
+
+func TestUnzipStreams_DiscardBoth(t *testing.T) {
+	closed := 0
+	keys, vals := UnzipStreams(OfMap(map[string]int{"a": 1, "b": 2}).OnClose(func() { closed++ }))
+	keys.Close()
+	assert.Zero(t, closed)
+	vals.Close()
+	assert.Equal(t, 1, closed)
+	// closing them again does not invoke the close handlers again
+	keys.Close()
+	vals.Close()
+	assert.Equal(t, 1, closed)
+}
+
+func TestUnzipStreams_DiscardAfterOtherFinished(t *testing.T) {
+	closed := 0
+	keys, vals := UnzipStreams(OfMap(map[string]int{"a": 1, "b": 2}).OnClose(func() { closed++ }))
+	assert.ElementsMatch(t, []string{"a", "b"}, keys.ToSlice())
+	assert.Zero(t, closed)
+	vals.Close()
+	assert.Equal(t, 1, closed)
+}
+
+func TestUnzipStreams_NotBlockedByOtherSide(t *testing.T) {
+	src := make(chan item.Pair[int, int])
+	evens, odds := UnzipStreams(OfChannel(src))
+	evensOut := make(chan int)
+	go func() {
+		evens.ForEach(func(n int) { evensOut <- n })
+		close(evensOut)
+	}()
+	src <- item.Pair[int, int]{Key: 0, Val: 1}
+	assert.Equal(t, 0, <-evensOut)
+	// the buffered element is consumed while the evens stream is waiting for the input
+	n, ok := odds.FindFirst()
+	assert.True(t, ok)
+	assert.Equal(t, 1, n)
+	close(src)
+	_, ok = <-evensOut
+	assert.False(t, ok)
+}
+
+func TestUnzipStreams_InputPanic(t *testing.T) {
+	letters, numbers := UnzipStreams(Map(Zip(Of("a", "b", "c"), Of(1, 2, 3)),
+		func(p item.Pair[string, int]) item.Pair[string, int] {
+			panicsOn(2)(p.Val)
+			return p
+		}))
+	assert.PanicsWithValue(t, "boom", func() {
+		letters.ToSlice()
+	})
+	// the elements that were pulled before the panic are still delivered
+	assert.Equal(t, []int{1}, numbers.ToSlice())
+}